@@ -0,0 +1,59 @@
+// Package backoff provides ready-made SleepDurationProviders
+//
+// Every provider allows the given number of retries, i.e. it reports ok for tries lower than retries.
+package backoff
+
+import (
+	"math"
+	"time"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// Constant sleeps the same delay before every retry
+func Constant(delay time.Duration, retries int) policy.SleepDurationProvider {
+	return func(try int) (time.Duration, bool) {
+		return delay, try < retries
+	}
+}
+
+// Linear sleeps initial before the first retry and step longer before each following one
+func Linear(initial, step time.Duration, retries int) policy.SleepDurationProvider {
+	return func(try int) (time.Duration, bool) {
+		return initial + step*time.Duration(try), try < retries
+	}
+}
+
+// Exponential doubles the delay with every retry starting with initial, but never sleeps longer than max
+func Exponential(initial, max time.Duration, retries int) policy.SleepDurationProvider {
+	return func(try int) (time.Duration, bool) {
+		return exponential(initial, max, try), try < retries
+	}
+}
+
+// Fibonacci grows the delay along the fibonacci sequence starting with initial, but never sleeps longer than max
+func Fibonacci(initial, max time.Duration, retries int) policy.SleepDurationProvider {
+	return func(try int) (time.Duration, bool) {
+		previous, current := time.Duration(0), initial
+		for i := 0; i < try && current < max; i++ {
+			previous, current = current, previous+current
+		}
+		return minDuration(current, max), try < retries
+	}
+}
+
+// exponential returns base*2^try capped at max, guarding against overflows
+func exponential(base, max time.Duration, try int) time.Duration {
+	delay := float64(base) * math.Pow(2, float64(try))
+	if delay >= float64(max) {
+		return max
+	}
+	return time.Duration(delay)
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
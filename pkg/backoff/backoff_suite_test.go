@@ -0,0 +1,15 @@
+package backoff_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BackoffSuite struct {
+	suite.Suite
+}
+
+func TestBackoff(t *testing.T) {
+	suite.Run(t, new(BackoffSuite))
+}
@@ -0,0 +1,88 @@
+package backoff_test
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/backoff"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+func (test *BackoffSuite) TestConstantSleepsSameDelay() {
+	provider := backoff.Constant(time.Second, 3)
+
+	assert.Equal(test.T(), []time.Duration{time.Second, time.Second, time.Second}, durations(provider, 3))
+}
+
+func (test *BackoffSuite) TestLinearGrowsByStep() {
+	provider := backoff.Linear(time.Second, time.Millisecond*500, 4)
+
+	expected := []time.Duration{time.Second, time.Millisecond * 1500, time.Second * 2, time.Millisecond * 2500}
+	assert.Equal(test.T(), expected, durations(provider, 4))
+}
+
+func (test *BackoffSuite) TestExponentialDoublesUpToMax() {
+	provider := backoff.Exponential(time.Second, time.Second*5, 5)
+
+	expected := []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5}
+	assert.Equal(test.T(), expected, durations(provider, 5))
+}
+
+func (test *BackoffSuite) TestExponentialDoesNotOverflow() {
+	provider := backoff.Exponential(time.Second, time.Hour, 1000)
+
+	dur, _ := provider(999)
+	assert.Equal(test.T(), time.Hour, dur)
+}
+
+func (test *BackoffSuite) TestFibonacciGrowsAlongSequenceUpToMax() {
+	provider := backoff.Fibonacci(time.Second, time.Second*6, 6)
+
+	expected := []time.Duration{time.Second, time.Second, time.Second * 2, time.Second * 3, time.Second * 5, time.Second * 6}
+	assert.Equal(test.T(), expected, durations(provider, 6))
+}
+
+func (test *BackoffSuite) TestProvidersAllowConfiguredRetries() {
+	providers := []policy.SleepDurationProvider{
+		backoff.Constant(time.Second, 2),
+		backoff.Linear(time.Second, time.Second, 2),
+		backoff.Exponential(time.Second, time.Minute, 2),
+		backoff.Fibonacci(time.Second, time.Minute, 2),
+		backoff.FullJitter(time.Second, time.Minute, 2),
+		backoff.EqualJitter(time.Second, time.Minute, 2),
+	}
+
+	for _, provider := range providers {
+		_, ok := provider(1)
+		assert.True(test.T(), ok, "provider did not allow configured retry")
+		_, ok = provider(2)
+		assert.False(test.T(), ok, "provider allowed more retries than configured")
+	}
+
+	decorrelated := backoff.DecorrelatedJitter(time.Second, time.Minute, 2)
+	_, ok := decorrelated(1, time.Second)
+	assert.True(test.T(), ok, "decorrelated jitter did not allow configured retry")
+	_, ok = decorrelated(2, time.Second)
+	assert.False(test.T(), ok, "decorrelated jitter allowed more retries than configured")
+}
+
+// durations returns the durations the given provider returns for the first tries
+func durations(provider policy.SleepDurationProvider, tries int) []time.Duration {
+	durations := []time.Duration{}
+	for try := 0; try < tries; try++ {
+		dur, _ := provider(try)
+		durations = append(durations, dur)
+	}
+	return durations
+}
+
+// decorrelatedDurations returns the durations the given provider returns for the first tries, each following the one before
+func decorrelatedDurations(provider policy.DelaySleepDurationProvider, tries int) []time.Duration {
+	durations := []time.Duration{}
+	var previous time.Duration
+	for try := 0; try < tries; try++ {
+		previous, _ = provider(try, previous)
+		durations = append(durations, previous)
+	}
+	return durations
+}
@@ -0,0 +1,100 @@
+package backoff
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// FullJitter sleeps a random delay between zero and the exponential delay capped at max
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func FullJitter(base, max time.Duration, retries int, opts ...JitterOption) policy.SleepDurationProvider {
+	rnd := newRandom(opts)
+
+	return func(try int) (time.Duration, bool) {
+		return rnd.between(0, exponential(base, max, try)), try < retries
+	}
+}
+
+// EqualJitter sleeps half of the exponential delay capped at max plus a random delay up to the other half
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func EqualJitter(base, max time.Duration, retries int, opts ...JitterOption) policy.SleepDurationProvider {
+	rnd := newRandom(opts)
+
+	return func(try int) (time.Duration, bool) {
+		half := exponential(base, max, try) / 2
+		return half + rnd.between(0, half), try < retries
+	}
+}
+
+// DecorrelatedJitter sleeps a random delay between base and three times the previous delay, but never longer than max
+// The first retry is treated as following a delay of base.
+// As every delay only depends on the previous one handed in by the RetryPolicy, the provider can be shared by executions running concurrently.
+// It is set by policy.WithDelaySleepDurationProvider.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func DecorrelatedJitter(base, max time.Duration, retries int, opts ...JitterOption) policy.DelaySleepDurationProvider {
+	rnd := newRandom(opts)
+
+	return func(try int, previous time.Duration) (time.Duration, bool) {
+		if previous < base {
+			previous = base
+		}
+
+		upper := max
+		if previous < max/3 {
+			upper = previous * 3
+		}
+
+		return minDuration(max, rnd.between(base, upper)), try < retries
+	}
+}
+
+// JitterOption modifies the randomness of jittered providers
+type JitterOption func(*jitterOptions)
+
+// WithSeed makes the provider's randomness reproducible by seeding it with the given value
+func WithSeed(seed int64) JitterOption {
+	return func(o *jitterOptions) {
+		o.source = rand.NewSource(seed)
+	}
+}
+
+// WithSource sets the source of the provider's randomness
+func WithSource(source rand.Source) JitterOption {
+	return func(o *jitterOptions) {
+		o.source = source
+	}
+}
+
+type jitterOptions struct {
+	source rand.Source
+}
+
+// random is a concurrency-safe source of random durations
+type random struct {
+	mux sync.Mutex
+	rnd *rand.Rand
+}
+
+func newRandom(opts []JitterOption) *random {
+	options := &jitterOptions{source: rand.NewSource(time.Now().UnixNano())}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return &random{rnd: rand.New(options.source)}
+}
+
+// between returns a random duration in [min, max]
+func (it *random) between(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return min + time.Duration(it.rnd.Int63n(int64(max-min)+1))
+}
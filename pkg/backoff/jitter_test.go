@@ -0,0 +1,97 @@
+package backoff_test
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/backoff"
+)
+
+func (test *BackoffSuite) TestFullJitterStaysWithinExponentialDelay() {
+	provider := backoff.FullJitter(time.Second, time.Second*10, 100, backoff.WithSeed(1))
+
+	for try := 0; try < 100; try++ {
+		dur, _ := provider(try)
+		assert.True(test.T(), dur >= 0, "delay negative")
+		assert.True(test.T(), dur <= capped(time.Second, time.Second*10, try), "delay exceeds exponential delay")
+	}
+}
+
+func (test *BackoffSuite) TestEqualJitterSleepsAtLeastHalfTheExponentialDelay() {
+	provider := backoff.EqualJitter(time.Second, time.Second*10, 100, backoff.WithSeed(1))
+
+	for try := 0; try < 100; try++ {
+		dur, _ := provider(try)
+		delay := capped(time.Second, time.Second*10, try)
+		assert.True(test.T(), dur >= delay/2, "delay below half the exponential delay")
+		assert.True(test.T(), dur <= delay, "delay exceeds exponential delay")
+	}
+}
+
+func (test *BackoffSuite) TestDecorrelatedJitterStaysWithinThreeTimesThePreviousDelay() {
+	provider := backoff.DecorrelatedJitter(time.Second, time.Second*10, 100, backoff.WithSeed(1))
+
+	previous := time.Duration(0)
+	for try := 0; try < 100; try++ {
+		dur, _ := provider(try, previous)
+		assert.True(test.T(), dur >= time.Second, "delay below base")
+		assert.True(test.T(), dur <= time.Second*10, "delay exceeds max")
+		assert.True(test.T(), dur <= time.Second*3 || dur <= previous*3, "delay exceeds three times the previous delay")
+		previous = dur
+	}
+}
+
+func (test *BackoffSuite) TestDecorrelatedJitterGrowsFromPreviousDelay() {
+	provider := backoff.DecorrelatedJitter(time.Second, time.Hour, 100, backoff.WithSeed(1))
+
+	for i := 0; i < 100; i++ {
+		dur, _ := provider(0, time.Minute)
+		assert.True(test.T(), dur <= time.Minute*3, "delay exceeds three times the previous delay")
+	}
+
+	grown := false
+	for i := 0; i < 100 && !grown; i++ {
+		dur, _ := provider(0, time.Minute)
+		grown = dur > time.Second*3
+	}
+	assert.True(test.T(), grown, "delay not derived from the previous delay")
+}
+
+func (test *BackoffSuite) TestDecorrelatedJitterOnlyDependsOnPreviousDelay() {
+	provider := backoff.DecorrelatedJitter(time.Second, time.Minute, 10, backoff.WithSeed(1))
+
+	_, _ = provider(0, 0)
+	_, _ = provider(1, time.Second*3)
+	_, _ = provider(2, time.Second*9)
+	for i := 0; i < 100; i++ {
+		dur, _ := provider(0, 0)
+		assert.True(test.T(), dur <= time.Second*3, "first delay depends on other executions' delays")
+	}
+}
+
+func (test *BackoffSuite) TestSeededJitterIsReproducible() {
+	first := backoff.DecorrelatedJitter(time.Second, time.Minute, 10, backoff.WithSeed(42))
+	second := backoff.DecorrelatedJitter(time.Second, time.Minute, 10, backoff.WithSeed(42))
+
+	assert.Equal(test.T(), decorrelatedDurations(first, 10), decorrelatedDurations(second, 10))
+}
+
+func (test *BackoffSuite) TestJitterSpreadsDelays() {
+	provider := backoff.FullJitter(time.Second, time.Minute, 10, backoff.WithSeed(1))
+
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 10; i++ {
+		dur, _ := provider(5)
+		seen[dur] = true
+	}
+
+	assert.True(test.T(), len(seen) > 1, "jitter returned the same delay every time")
+}
+
+func capped(base, max time.Duration, try int) time.Duration {
+	delay := base << uint(try)
+	if delay > max || delay <= 0 {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,112 @@
+// Package metrics records metrics of policies and serves them in the Prometheus text exposition format
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the execution latency histogram's buckets
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector collects the metrics of all policies instrumented by it
+type Collector struct {
+	buckets []float64
+	clock   policy.Clock
+
+	mux      sync.Mutex
+	policies map[string]*policyMetrics
+}
+
+// NewCollector creates a Collector
+func NewCollector(opts ...Option) *Collector {
+	collector := &Collector{
+		buckets:  DefaultBuckets,
+		clock:    policy.SystemClock(),
+		policies: map[string]*policyMetrics{},
+	}
+
+	for _, opt := range opts {
+		opt(collector)
+	}
+
+	return collector
+}
+
+// Option modifies the Collector
+type Option func(*Collector)
+
+// WithBuckets sets the upper bounds in seconds of the execution latency histogram's buckets
+func WithBuckets(buckets ...float64) Option {
+	return func(o *Collector) {
+		o.buckets = append([]float64{}, buckets...)
+		sort.Float64s(o.buckets)
+	}
+}
+
+// WithClock sets the Clock execution latencies are measured with
+func WithClock(clock policy.Clock) Option {
+	return func(o *Collector) {
+		o.clock = clock
+	}
+}
+
+// Instrument returns a policy applying the given one and recording its metrics labelled with the given name
+// Circuit metrics are recorded for the given policy if it is a circuit breaker,
+// or for the outermost circuit breaker if it is a PolicyWrap or a policy.Decorator like a traced policy
+// Instrumenting several policies with the same name makes them share their metrics
+func (it *Collector) Instrument(name string, plcy policy.Policy) policy.Policy {
+	metrics := it.metricsOf(name)
+	if breakers := policy.CircuitBreakers(plcy); len(breakers) > 0 {
+		metrics.watch(breakers[0])
+	}
+
+	return &instrumentedPolicy{
+		policy:  plcy,
+		metrics: metrics,
+		clock:   it.clock,
+	}
+}
+
+// Handler returns an http.Handler serving the collected metrics in the Prometheus text exposition format
+func (it *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_ = it.write(w)
+	})
+}
+
+func (it *Collector) metricsOf(name string) *policyMetrics {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	metrics, ok := it.policies[name]
+	if !ok {
+		metrics = newPolicyMetrics(name, it.buckets)
+		it.policies[name] = metrics
+	}
+
+	return metrics
+}
+
+// snapshots returns the current metrics of all policies ordered by name
+func (it *Collector) snapshots() []snapshot {
+	it.mux.Lock()
+	all := make([]*policyMetrics, 0, len(it.policies))
+	for _, metrics := range it.policies {
+		all = append(all, metrics)
+	}
+	it.mux.Unlock()
+
+	sort.Slice(all, func(i, j int) bool { return all[i].name < all[j].name })
+
+	snapshots := make([]snapshot, 0, len(all))
+	for _, metrics := range all {
+		snapshots = append(snapshots, metrics.snapshot())
+	}
+
+	return snapshots
+}
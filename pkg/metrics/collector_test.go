@@ -0,0 +1,235 @@
+package metrics_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/metrics"
+	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/policytest"
+	"github.com/typusomega/poligo/pkg/tracing"
+)
+
+func (test *MetricsSuite) TestHandlerServesTextExpositionFormat() {
+	recorder := httptest.NewRecorder()
+
+	metrics.NewCollector().Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(test.T(), 200, recorder.Code)
+	assert.Equal(test.T(), "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Contains(test.T(), recorder.Body.String(), "# TYPE poligo_executions_total counter\n")
+}
+
+func (test *MetricsSuite) TestRecordsExecutionsPerPolicy() {
+	collector := metrics.NewCollector()
+	succeeding := collector.Instrument("succeeding", policy.DefaultTimeoutPolicy())
+	failing := collector.Instrument("failing", policy.DefaultTimeoutPolicy())
+
+	_ = succeeding.ExecuteVoid(context.Background(), func() error { return nil })
+	_, _ = succeeding.Execute(context.Background(), func() (interface{}, error) { return "test", nil })
+	_ = failing.ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	body := scrape(collector)
+	assert.Contains(test.T(), body, `poligo_executions_total{policy="succeeding"} 2`)
+	assert.Contains(test.T(), body, `poligo_successes_total{policy="succeeding"} 2`)
+	assert.Contains(test.T(), body, `poligo_handled_failures_total{policy="succeeding"} 0`)
+	assert.Contains(test.T(), body, `poligo_executions_total{policy="failing"} 1`)
+	assert.Contains(test.T(), body, `poligo_successes_total{policy="failing"} 0`)
+	assert.Contains(test.T(), body, `poligo_handled_failures_total{policy="failing"} 1`)
+}
+
+func (test *MetricsSuite) TestRecordsRetriesPerAttemptNumber() {
+	collector := metrics.NewCollector()
+	retry := policy.HandleAll().Retry(policy.WithRetries(2))
+	retry.SleepDurationProvider = func(int) (time.Duration, bool) { return 0, false }
+
+	_ = collector.Instrument("retry", retry).ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	body := scrape(collector)
+	assert.Contains(test.T(), body, `poligo_executions_total{policy="retry"} 1`)
+	assert.Contains(test.T(), body, `poligo_handled_failures_total{policy="retry"} 3`)
+	assert.Contains(test.T(), body, `poligo_retries_total{policy="retry",attempt="2"} 1`)
+	assert.Contains(test.T(), body, `poligo_retries_total{policy="retry",attempt="3"} 1`)
+	assert.NotContains(test.T(), body, `attempt="1"`, "first attempt counted as retry")
+}
+
+func (test *MetricsSuite) TestRecordsRetriesRejectedWithinRetry() {
+	collector := metrics.NewCollector()
+	retry := policy.HandleAll().Retry(policy.WithRetries(3), policy.WithDurations(time.Nanosecond))
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker()
+	circuitBreaker.MaxErrors = 0
+	circuitBreaker.BrokenForProvider = func(int) (time.Duration, bool) { return time.Hour, true }
+
+	_ = collector.Instrument("wrap", policy.Wrap(retry, circuitBreaker)).ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	body := scrape(collector)
+	assert.Contains(test.T(), body, `poligo_handled_failures_total{policy="wrap"} 4`)
+	for _, attempt := range []string{"2", "3", "4"} {
+		assert.Contains(test.T(), body, `poligo_retries_total{policy="wrap",attempt="`+attempt+`"} 1`, "rejected retry %s not recorded", attempt)
+	}
+}
+
+func (test *MetricsSuite) TestRecordsRetriesOfTracedPolicy() {
+	collector := metrics.NewCollector()
+	retry := policy.HandleAll().Retry(policy.WithRetries(1), policy.WithDurations(time.Nanosecond))
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker()
+	circuitBreaker.MaxErrors = 0
+	circuitBreaker.BrokenForProvider = func(int) (time.Duration, bool) { return time.Hour, true }
+	plcy := tracing.Instrument("traced", policy.Wrap(retry, circuitBreaker))
+
+	_ = collector.Instrument("traced", plcy).ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	assert.Contains(test.T(), scrape(collector), `poligo_retries_total{policy="traced",attempt="2"} 1`, "retry observed by tracing only")
+}
+
+func (test *MetricsSuite) TestUnhandledErrorsAreNoHandledFailures() {
+	collector := metrics.NewCollector()
+	retry := policy.HandleResult(func(val interface{}) bool { return val == 503 }).Retry()
+
+	_ = collector.Instrument("retry", retry).ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	assert.Contains(test.T(), scrape(collector), `poligo_handled_failures_total{policy="retry"} 0`)
+}
+
+func (test *MetricsSuite) TestRecordsCircuitMetrics() {
+	collector := metrics.NewCollector()
+	onBreakCalled := false
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker(policy.WithOnBreakCallback(func(error, time.Duration) { onBreakCalled = true }))
+	circuitBreaker.BrokenForProvider = func(int) (time.Duration, bool) { return time.Hour, true }
+	plcy := collector.Instrument("circuit", circuitBreaker)
+
+	body := scrape(collector)
+	assert.Contains(test.T(), body, `poligo_circuit_state{policy="circuit",state="closed"} 1`)
+	assert.Contains(test.T(), body, `poligo_circuit_breaks_total{policy="circuit"} 0`)
+
+	_ = plcy.ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+	_ = plcy.ExecuteVoid(context.Background(), func() error { return nil })
+
+	body = scrape(collector)
+	assert.True(test.T(), onBreakCalled, "OnBreak callback not called anymore")
+	assert.Contains(test.T(), body, `poligo_circuit_state{policy="circuit",state="closed"} 0`)
+	assert.Contains(test.T(), body, `poligo_circuit_state{policy="circuit",state="open"} 1`)
+	assert.Contains(test.T(), body, `poligo_circuit_breaks_total{policy="circuit"} 1`)
+	assert.Contains(test.T(), body, `poligo_rejected_calls_total{policy="circuit"} 1`)
+	assert.Contains(test.T(), body, `poligo_executions_total{policy="circuit"} 2`)
+}
+
+func (test *MetricsSuite) TestRecordsCircuitMetricsWithinWrap() {
+	collector := metrics.NewCollector()
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Isolate()
+	plcy := collector.Instrument("wrap", policy.Wrap(policy.DefaultRetryPolicy(), circuitBreaker))
+
+	_ = plcy.ExecuteVoid(context.Background(), func() error { return nil })
+
+	body := scrape(collector)
+	assert.Contains(test.T(), body, `poligo_circuit_state{policy="wrap",state="isolated"} 1`)
+	assert.Contains(test.T(), body, `poligo_rejected_calls_total{policy="wrap"} 1`)
+}
+
+func (test *MetricsSuite) TestRecordsCircuitMetricsOfTracedPolicy() {
+	collector := metrics.NewCollector()
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker()
+	plcy := collector.Instrument("traced", tracing.Instrument("traced", policy.Wrap(policy.DefaultRetryPolicy(), circuitBreaker)))
+
+	_ = plcy.ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	body := scrape(collector)
+	assert.Contains(test.T(), body, `poligo_circuit_state{policy="traced",state="open"} 1`)
+	assert.Contains(test.T(), body, `poligo_circuit_breaks_total{policy="traced"} 1`)
+}
+
+func (test *MetricsSuite) TestIsolationIsNoCircuitBreak() {
+	collector := metrics.NewCollector()
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	collector.Instrument("circuit", circuitBreaker)
+
+	circuitBreaker.Isolate()
+
+	body := scrape(collector)
+	assert.Contains(test.T(), body, `poligo_circuit_state{policy="circuit",state="isolated"} 1`)
+	assert.Contains(test.T(), body, `poligo_circuit_breaks_total{policy="circuit"} 0`, "isolation counted as break")
+}
+
+func (test *MetricsSuite) TestOmitsCircuitMetricsOfPoliciesWithoutCircuit() {
+	collector := metrics.NewCollector()
+	collector.Instrument("retry", policy.DefaultRetryPolicy())
+
+	body := scrape(collector)
+	assert.NotContains(test.T(), body, `poligo_circuit_state{`)
+	assert.NotContains(test.T(), body, `poligo_circuit_breaks_total{`)
+}
+
+func (test *MetricsSuite) TestRecordsExecutionLatency() {
+	clock := policytest.NewFakeClock(time.Now())
+	collector := metrics.NewCollector(metrics.WithClock(clock), metrics.WithBuckets(1, 0.1))
+	plcy := collector.Instrument("timeout", policy.DefaultTimeoutPolicy())
+
+	_ = plcy.ExecuteVoid(context.Background(), func() error {
+		clock.Advance(time.Millisecond * 500)
+		return nil
+	})
+
+	body := scrape(collector)
+	assert.Contains(test.T(), body, "# TYPE poligo_execution_duration_seconds histogram\n")
+	assert.Contains(test.T(), body, `poligo_execution_duration_seconds_bucket{policy="timeout",le="0.1"} 0`)
+	assert.Contains(test.T(), body, `poligo_execution_duration_seconds_bucket{policy="timeout",le="1"} 1`)
+	assert.Contains(test.T(), body, `poligo_execution_duration_seconds_bucket{policy="timeout",le="+Inf"} 1`)
+	assert.Contains(test.T(), body, `poligo_execution_duration_seconds_sum{policy="timeout"} 0.5`)
+	assert.Contains(test.T(), body, `poligo_execution_duration_seconds_count{policy="timeout"} 1`)
+}
+
+func (test *MetricsSuite) TestPoliciesWithSameNameShareMetrics() {
+	collector := metrics.NewCollector()
+
+	_ = collector.Instrument("shared", policy.DefaultRetryPolicy()).ExecuteVoid(context.Background(), func() error { return nil })
+	_ = collector.Instrument("shared", policy.DefaultTimeoutPolicy()).ExecuteVoid(context.Background(), func() error { return nil })
+
+	assert.Contains(test.T(), scrape(collector), `poligo_executions_total{policy="shared"} 2`)
+}
+
+func (test *MetricsSuite) TestInstrumentsCircuitBreakerServingExecutions() {
+	collector := metrics.NewCollector()
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		collector.Instrument(fmt.Sprintf("circuit-%d", i), circuitBreaker)
+	}
+	close(done)
+	wg.Wait()
+
+	assert.Len(test.T(), circuitBreaker.Listeners, 10)
+}
+
+func (test *MetricsSuite) TestStopsWatchingReplacedCircuitBreaker() {
+	collector := metrics.NewCollector()
+	replaced := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+
+	collector.Instrument("circuit", replaced)
+	collector.Instrument("circuit", circuitBreaker)
+	collector.Instrument("circuit", circuitBreaker)
+
+	assert.Empty(test.T(), replaced.Listeners, "replaced circuit breaker still watched")
+	assert.Len(test.T(), circuitBreaker.Listeners, 1)
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// contentType is the content type of the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	executionsMetric       = "poligo_executions_total"
+	successesMetric        = "poligo_successes_total"
+	failuresMetric         = "poligo_handled_failures_total"
+	retriesMetric          = "poligo_retries_total"
+	rejectionsMetric       = "poligo_rejected_calls_total"
+	circuitStateMetric     = "poligo_circuit_state"
+	circuitBreaksMetric    = "poligo_circuit_breaks_total"
+	executionLatencyMetric = "poligo_execution_duration_seconds"
+)
+
+var circuitStates = []policy.CircuitState{policy.CircuitClosed, policy.CircuitOpen, policy.CircuitHalfOpen, policy.CircuitIsolated}
+
+// write writes the metrics of all policies in the Prometheus text exposition format
+func (it *Collector) write(w io.Writer) error {
+	snapshots := it.snapshots()
+	out := bufio.NewWriter(w)
+
+	writeCounter(out, executionsMetric, "Number of executions of the policy.", snapshots,
+		func(snap snapshot) uint64 { return snap.executions })
+	writeCounter(out, successesMetric, "Number of executions of the policy succeeding.", snapshots,
+		func(snap snapshot) uint64 { return snap.successes })
+	writeCounter(out, failuresMetric, "Number of attempts failing with an outcome handled by the policy.", snapshots,
+		func(snap snapshot) uint64 { return snap.failures })
+
+	writeHeader(out, retriesMetric, "Number of retries by attempt number.", "counter")
+	for _, snap := range snapshots {
+		for _, retry := range snap.retries {
+			writeSample(out, retriesMetric, labels(snap.name, "attempt", strconv.Itoa(retry.attempt)), float64(retry.count))
+		}
+	}
+
+	writeCounter(out, rejectionsMetric, "Number of executions rejected by a broken circuit.", snapshots,
+		func(snap snapshot) uint64 { return snap.rejections })
+
+	writeHeader(out, circuitStateMetric, "Current state of the policy's circuit, 1 for the state the circuit is in.", "gauge")
+	for _, snap := range snapshots {
+		if !snap.hasCircuit {
+			continue
+		}
+		for _, state := range circuitStates {
+			value := 0.0
+			if state == snap.state {
+				value = 1
+			}
+			writeSample(out, circuitStateMetric, labels(snap.name, "state", state.String()), value)
+		}
+	}
+
+	writeHeader(out, circuitBreaksMetric, "Number of times the policy's circuit was broken.", "counter")
+	for _, snap := range snapshots {
+		if snap.hasCircuit {
+			writeSample(out, circuitBreaksMetric, labels(snap.name), float64(snap.breaks))
+		}
+	}
+
+	writeHeader(out, executionLatencyMetric, "Duration of the policy's executions in seconds.", "histogram")
+	for _, snap := range snapshots {
+		for i, bound := range snap.buckets {
+			writeSample(out, executionLatencyMetric+"_bucket", labels(snap.name, "le", formatFloat(bound)), float64(snap.bucketCounts[i]))
+		}
+		writeSample(out, executionLatencyMetric+"_bucket", labels(snap.name, "le", "+Inf"), float64(snap.executions))
+		writeSample(out, executionLatencyMetric+"_sum", labels(snap.name), snap.latencySum)
+		writeSample(out, executionLatencyMetric+"_count", labels(snap.name), float64(snap.executions))
+	}
+
+	return out.Flush()
+}
+
+func writeCounter(out *bufio.Writer, name, help string, snapshots []snapshot, value func(snapshot) uint64) {
+	writeHeader(out, name, help, "counter")
+	for _, snap := range snapshots {
+		writeSample(out, name, labels(snap.name), float64(value(snap)))
+	}
+}
+
+func writeHeader(out *bufio.Writer, name, help, metricType string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeSample(out *bufio.Writer, name, labels string, value float64) {
+	fmt.Fprintf(out, "%s{%s} %s\n", name, labels, formatFloat(value))
+}
+
+// labels renders the policy label followed by the given pairs of label names and values
+func labels(policyName string, pairs ...string) string {
+	rendered := []string{`policy="` + escapeLabelValue(policyName) + `"`}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		rendered = append(rendered, pairs[i]+`="`+escapeLabelValue(pairs[i+1])+`"`)
+	}
+
+	return strings.Join(rendered, ",")
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
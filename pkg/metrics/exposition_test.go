@@ -0,0 +1,37 @@
+package metrics_test
+
+import (
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/metrics"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+func (test *MetricsSuite) TestEscapesPolicyNames() {
+	collector := metrics.NewCollector()
+	collector.Instrument("a \"quoted\"\\name\n", policy.DefaultRetryPolicy())
+
+	assert.Contains(test.T(), scrape(collector), `poligo_executions_total{policy="a \"quoted\"\\name\n"} 0`)
+}
+
+func (test *MetricsSuite) TestOrdersPoliciesByName() {
+	collector := metrics.NewCollector()
+	collector.Instrument("b", policy.DefaultRetryPolicy())
+	collector.Instrument("a", policy.DefaultRetryPolicy())
+
+	body := scrape(collector)
+	assert.True(test.T(), strings.Index(body, `{policy="a"}`) < strings.Index(body, `{policy="b"}`), "policies not ordered by name")
+}
+
+func (test *MetricsSuite) TestEveryMetricHasHelpAndType() {
+	body := scrape(metrics.NewCollector())
+
+	for _, name := range []string{
+		"poligo_executions_total", "poligo_successes_total", "poligo_handled_failures_total", "poligo_retries_total",
+		"poligo_rejected_calls_total", "poligo_circuit_state", "poligo_circuit_breaks_total", "poligo_execution_duration_seconds",
+	} {
+		assert.Contains(test.T(), body, "# HELP "+name+" ", "HELP missing")
+		assert.Contains(test.T(), body, "# TYPE "+name+" ", "TYPE missing")
+	}
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// instrumentedPolicy applies a policy and records its metrics
+type instrumentedPolicy struct {
+	policy  policy.Policy
+	metrics *policyMetrics
+	clock   policy.Clock
+}
+
+// Unwrap returns the instrumented policy
+func (it *instrumentedPolicy) Unwrap() policy.Policy {
+	return it.policy
+}
+
+// ExecuteVoid calls the given action and applies the policy
+func (it *instrumentedPolicy) ExecuteVoid(ctx context.Context, action func() error) error {
+	return it.ExecuteVoidContext(ctx, func(context.Context) error { return action() })
+}
+
+// Execute calls the given action and applies the policy
+func (it *instrumentedPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	return it.ExecuteContext(ctx, func(context.Context) (interface{}, error) { return action() })
+}
+
+// ExecuteVoidContext calls the given action and applies the policy
+func (it *instrumentedPolicy) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
+	start := it.clock.Now()
+	ctx = policy.WithAttemptObserver(ctx, it.observeAttempt)
+	err := it.policy.ExecuteVoidContext(ctx, func(ctx context.Context) error {
+		err := action(ctx)
+		if !it.isObservedAttempt(ctx) {
+			it.recordAttempt(ctx, nil, err)
+		}
+		return err
+	})
+	it.metrics.recordExecution(err, it.clock.Now().Sub(start))
+
+	return err
+}
+
+// ExecuteContext calls the given action and applies the policy
+func (it *instrumentedPolicy) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	start := it.clock.Now()
+	ctx = policy.WithAttemptObserver(ctx, it.observeAttempt)
+	val, err := it.policy.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		val, err := action(ctx)
+		if !it.isObservedAttempt(ctx) {
+			it.recordAttempt(ctx, val, err)
+		}
+		return val, err
+	})
+	it.metrics.recordExecution(err, it.clock.Now().Sub(start))
+
+	return val, err
+}
+
+// observeAttempt records an attempt made by a RetryPolicy, even if a policy nested within it rejects the attempt
+func (it *instrumentedPolicy) observeAttempt(ctx context.Context, attempt policy.Attempt) (context.Context, func(interface{}, error)) {
+	ctx = context.WithValue(ctx, observedAttemptKey{}, it)
+
+	return ctx, func(val interface{}, err error) {
+		it.metrics.recordAttempt(attempt.Number, handles(it.policy, val, err))
+	}
+}
+
+// observedAttemptKey marks the context of an attempt recorded by the instrumentedPolicy it carries
+type observedAttemptKey struct{}
+
+// isObservedAttempt reports whether the attempt handing over the context is recorded by the policy already
+func (it *instrumentedPolicy) isObservedAttempt(ctx context.Context) bool {
+	observed, _ := ctx.Value(observedAttemptKey{}).(*instrumentedPolicy)
+	return observed == it
+}
+
+func (it *instrumentedPolicy) recordAttempt(ctx context.Context, val interface{}, err error) {
+	number := 1
+	if attempt, ok := policy.AttemptFromContext(ctx); ok {
+		number = attempt.Number
+	}
+
+	it.metrics.recordAttempt(number, handles(it.policy, val, err))
+}
+
+// handles reports whether the policy handles the outcome of an attempt
+// Policies not telling their handled outcomes are considered to handle all errors
+func handles(plcy policy.Policy, val interface{}, err error) bool {
+	if handler, ok := plcy.(policy.OutcomeHandler); ok {
+		return handler.Handles(val, err)
+	}
+
+	return err != nil
+}
+
+// isRejection reports whether an execution was rejected by a broken circuit
+func isRejection(err error) bool {
+	return errors.Is(err, policy.CircuitBrokenError{})
+}
@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// policyMetrics holds the metrics of a single named policy
+type policyMetrics struct {
+	name    string
+	buckets []float64
+
+	mux          sync.Mutex
+	executions   uint64
+	successes    uint64
+	failures     uint64
+	rejections   uint64
+	breaks       uint64
+	retries      map[int]uint64
+	bucketCounts []uint64
+	latencySum   float64
+	breaker      *policy.CircuitBreakerPolicy
+	unwatch      func()
+}
+
+func newPolicyMetrics(name string, buckets []float64) *policyMetrics {
+	return &policyMetrics{
+		name:         name,
+		buckets:      buckets,
+		retries:      map[int]uint64{},
+		bucketCounts: make([]uint64, len(buckets)),
+	}
+}
+
+// watch records the breaks of the given circuit breaker by listening to it
+// Isolating the circuit manually is no break. Watching another circuit breaker stops listening to the previous one.
+func (it *policyMetrics) watch(breaker *policy.CircuitBreakerPolicy) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	if it.breaker == breaker {
+		return
+	}
+	if it.unwatch != nil {
+		it.unwatch()
+	}
+	it.breaker = breaker
+
+	it.unwatch = breaker.AddListener(policy.ListenerFunc(func(event policy.Event) {
+		if event.Kind != policy.CircuitBroken || errors.As(event.Err, new(policy.IsolatedCircuitError)) {
+			return
+		}
+
+		it.mux.Lock()
+		it.breaks++
+		it.mux.Unlock()
+	}))
+}
+
+func (it *policyMetrics) recordAttempt(number int, handled bool) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	if number > 1 {
+		it.retries[number]++
+	}
+	if handled {
+		it.failures++
+	}
+}
+
+func (it *policyMetrics) recordExecution(err error, latency time.Duration) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	it.executions++
+	switch {
+	case err == nil:
+		it.successes++
+	case isRejection(err):
+		it.rejections++
+	}
+
+	secs := latency.Seconds()
+	it.latencySum += secs
+	for i, bound := range it.buckets {
+		if secs <= bound {
+			it.bucketCounts[i]++
+		}
+	}
+}
+
+func (it *policyMetrics) snapshot() snapshot {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	snap := snapshot{
+		name:         it.name,
+		executions:   it.executions,
+		successes:    it.successes,
+		failures:     it.failures,
+		rejections:   it.rejections,
+		breaks:       it.breaks,
+		buckets:      it.buckets,
+		bucketCounts: append([]uint64{}, it.bucketCounts...),
+		latencySum:   it.latencySum,
+		hasCircuit:   it.breaker != nil,
+	}
+	for attempt, count := range it.retries {
+		snap.retries = append(snap.retries, retryCount{attempt: attempt, count: count})
+	}
+	sort.Slice(snap.retries, func(i, j int) bool { return snap.retries[i].attempt < snap.retries[j].attempt })
+
+	if it.breaker != nil {
+		snap.state = it.breaker.State()
+	}
+
+	return snap
+}
+
+// snapshot is a consistent copy of a policy's metrics
+// bucketCounts are cumulative, i.e. every bucket counts all latencies lower or equal to its bound
+type snapshot struct {
+	name         string
+	executions   uint64
+	successes    uint64
+	failures     uint64
+	rejections   uint64
+	breaks       uint64
+	retries      []retryCount
+	buckets      []float64
+	bucketCounts []uint64
+	latencySum   float64
+	hasCircuit   bool
+	state        policy.CircuitState
+}
+
+type retryCount struct {
+	attempt int
+	count   uint64
+}
@@ -0,0 +1,24 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/typusomega/poligo/pkg/metrics"
+)
+
+type MetricsSuite struct {
+	suite.Suite
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
+
+// scrape returns the metrics served by the collector's handler
+func scrape(collector *metrics.Collector) string {
+	recorder := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	return recorder.Body.String()
+}
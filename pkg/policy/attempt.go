@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"context"
+	"time"
+)
+
+// Attempt describes a single attempt of an execution
+type Attempt struct {
+	// Number counts the attempts of an execution starting with 1
+	Number int
+	// Delay is the time slept before the attempt
+	Delay time.Duration
+}
+
+type attemptKey struct{}
+
+// AttemptFromContext returns the Attempt carried by the context handed to an action
+// It reports false if the action is not executed by a RetryPolicy
+func AttemptFromContext(ctx context.Context) (Attempt, bool) {
+	attempt, ok := ctx.Value(attemptKey{}).(Attempt)
+	return attempt, ok
+}
+
+func withAttempt(ctx context.Context, attempt Attempt) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+type noRetriesKey struct{}
+
+// WithoutRetries returns a context making every RetryPolicy executing with it give up after the first attempt
+// The outcome of that attempt is returned like the one of a RetryPolicy without any retries left.
+func WithoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+// retriesDisabled reports whether the context forbids retries
+func retriesDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noRetriesKey{}).(bool)
+	return disabled
+}
+
+// AttemptObserver is called by a RetryPolicy before every attempt of an execution whose context carries it
+// It returns the context handed to the attempt and a function called with the attempt's outcome.
+type AttemptObserver func(ctx context.Context, attempt Attempt) (context.Context, func(val interface{}, err error))
+
+type attemptObserverKey struct{}
+
+// WithAttemptObserver returns a context making the outermost RetryPolicy executing with it notify the observer about every attempt
+// Unlike the action, the observer sees attempts rejected by policies nested within the RetryPolicy as well, e.g. by a broken circuit.
+// Observers already carried by the context are notified as well, before the given one.
+func WithAttemptObserver(ctx context.Context, observer AttemptObserver) context.Context {
+	observers := attemptObservers(ctx)
+	return context.WithValue(ctx, attemptObserverKey{}, append(observers[:len(observers):len(observers)], observer))
+}
+
+func attemptObservers(ctx context.Context) []AttemptObserver {
+	observers, _ := ctx.Value(attemptObserverKey{}).([]AttemptObserver)
+	return observers
+}
+
+// observeAttempt notifies the context's AttemptObservers about the attempt, if any
+// The observers are not handed down to the attempt, so nested RetryPolicies don't report their attempts as well.
+// They are handed the outcome in reverse order, so an observer's work is nested within the ones of the observers before it.
+func observeAttempt(ctx context.Context, attempt Attempt) (context.Context, func(val interface{}, err error)) {
+	observers := attemptObservers(ctx)
+	if len(observers) == 0 {
+		return ctx, func(interface{}, error) {}
+	}
+
+	ctx = context.WithValue(ctx, attemptObserverKey{}, []AttemptObserver(nil))
+	observed := make([]func(val interface{}, err error), len(observers))
+	for i, observer := range observers {
+		ctx, observed[i] = observer(ctx, attempt)
+	}
+
+	return ctx, func(val interface{}, err error) {
+		for i := len(observed) - 1; i >= 0; i-- {
+			observed[i](val, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package policy_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+func (test *PolicySuite) TestRetryHandsAttemptToAction() {
+	attempts := []policy.Attempt{}
+	retry := policy.HandleAll().Retry(policy.WithDurations(time.Nanosecond, time.Nanosecond*2))
+
+	_, err := retry.ExecuteContext(context.Background(), func(ctx context.Context) (interface{}, error) {
+		attempt, ok := policy.AttemptFromContext(ctx)
+		assert.True(test.T(), ok, "no attempt handed to action")
+		attempts = append(attempts, attempt)
+		return nil, fmt.Errorf("fail")
+	})
+
+	assert.NotNil(test.T(), err)
+	assert.Equal(test.T(), []policy.Attempt{
+		{Number: 1, Delay: 0},
+		{Number: 2, Delay: time.Nanosecond},
+		{Number: 3, Delay: time.Nanosecond * 2},
+	}, attempts)
+}
+
+func (test *PolicySuite) TestVoidRetryHandsAttemptToAction() {
+	numbers := []int{}
+	retry := policy.DefaultRetryPolicy()
+
+	_ = retry.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+		attempt, _ := policy.AttemptFromContext(ctx)
+		numbers = append(numbers, attempt.Number)
+		return fmt.Errorf("fail")
+	})
+
+	assert.Equal(test.T(), []int{1, 2}, numbers)
+}
+
+func (test *PolicySuite) TestAttemptIsHandedThroughWrappedPolicies() {
+	numbers := []int{}
+	wrap := policy.Wrap(policy.DefaultRetryPolicy(), policy.DefaultCircuitBreakerPolicy(), policy.DefaultBulkheadPolicy())
+	wrap.(*policy.PolicyWrap).Policies()[1].(*policy.CircuitBreakerPolicy).MaxErrors = 5
+
+	_ = wrap.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+		attempt, _ := policy.AttemptFromContext(ctx)
+		numbers = append(numbers, attempt.Number)
+		return fmt.Errorf("fail")
+	})
+
+	assert.Equal(test.T(), []int{1, 2}, numbers, "attempt not handed through wrapped policies")
+}
+
+func (test *PolicySuite) TestRetryGivesUpAfterFirstAttemptWithoutRetries() {
+	calls := 0
+	retry := policy.HandleAll().Retry(policy.WithRetries(3), policy.WithDurations(time.Hour))
+
+	err := retry.ExecuteVoidContext(policy.WithoutRetries(context.Background()), func(context.Context) error {
+		calls++
+		return fmt.Errorf("fail")
+	})
+
+	assert.Equal(test.T(), 1, calls, "retried although forbidden by the context")
+	assert.IsType(test.T(), policy.RetryExhaustedError{}, err)
+}
+
+func (test *PolicySuite) TestAttemptObserverSeesRejectedAttempts() {
+	observed := []string{}
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.MaxErrors = 0
+	wrap := policy.Wrap(policy.DefaultRetryPolicy(), circuitBreaker)
+	ctx := policy.WithAttemptObserver(context.Background(), func(ctx context.Context, attempt policy.Attempt) (context.Context, func(interface{}, error)) {
+		return ctx, func(_ interface{}, err error) {
+			observed = append(observed, fmt.Sprintf("%d: %v", attempt.Number, err))
+		}
+	})
+
+	calls := 0
+	_ = wrap.ExecuteVoidContext(ctx, func(context.Context) error {
+		calls++
+		return fmt.Errorf("fail")
+	})
+
+	assert.Equal(test.T(), 1, calls)
+	assert.Equal(test.T(), []string{"1: fail", "2: circuit broken"}, observed)
+}
+
+func (test *PolicySuite) TestAllAttemptObserversAreNotified() {
+	observed := []string{}
+	observer := func(name string) policy.AttemptObserver {
+		return func(ctx context.Context, attempt policy.Attempt) (context.Context, func(interface{}, error)) {
+			observed = append(observed, fmt.Sprintf("%s started %d", name, attempt.Number))
+			return ctx, func(interface{}, error) {
+				observed = append(observed, fmt.Sprintf("%s finished %d", name, attempt.Number))
+			}
+		}
+	}
+	ctx := policy.WithAttemptObserver(policy.WithAttemptObserver(context.Background(), observer("outer")), observer("inner"))
+
+	_ = policy.DefaultRetryPolicy().ExecuteVoidContext(ctx, func(context.Context) error { return nil })
+
+	assert.Equal(test.T(), []string{"outer started 1", "inner started 1", "inner finished 1", "outer finished 1"}, observed)
+}
+
+func (test *PolicySuite) TestAttemptObserverIsNotHandedToNestedRetries() {
+	observed := []int{}
+	wrap := policy.Wrap(policy.DefaultRetryPolicy(), policy.DefaultRetryPolicy())
+	ctx := policy.WithAttemptObserver(context.Background(), func(ctx context.Context, attempt policy.Attempt) (context.Context, func(interface{}, error)) {
+		observed = append(observed, attempt.Number)
+		return ctx, func(interface{}, error) {}
+	})
+
+	_ = wrap.ExecuteVoidContext(ctx, func(context.Context) error { return fmt.Errorf("fail") })
+
+	assert.Equal(test.T(), []int{1, 2}, observed, "attempts of nested retries observed")
+}
+
+func (test *PolicySuite) TestNoAttemptOutsideOfRetries() {
+	_, ok := policy.AttemptFromContext(context.Background())
+
+	assert.False(test.T(), ok)
+}
@@ -1,6 +1,8 @@
 package policy
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"time"
 )
@@ -20,6 +22,21 @@ func HandleType(errorObj interface{}) ErrorBuilder {
 	}
 }
 
+// HandleErrorAs is the entrypoint to build policies handling errors matching the target according to errors.As
+// Like for errors.As the target is a pointer to the error type looked for, e.g. new(*MyError)
+func HandleErrorAs(target interface{}) ErrorBuilder {
+	return &builder{
+		handlePredicate: errorAsPredicate(target),
+	}
+}
+
+// HandleErrorIs is the entrypoint to build policies handling errors matching the sentinel according to errors.Is
+func HandleErrorIs(sentinel error) ErrorBuilder {
+	return &builder{
+		handlePredicate: func(err error) bool { return errors.Is(err, sentinel) },
+	}
+}
+
 // HandleAll handles all kinds of errors
 func HandleAll() Builder {
 	return &builder{
@@ -27,42 +44,114 @@ func HandleAll() Builder {
 	}
 }
 
+// HandleResult is the entrypoint to build policies handling results instead of errors
+// The given predicate is evaluated with the action's result after each execution not returning an error
+func HandleResult(predicate ResultPredicate) ErrorBuilder {
+	return &builder{
+		handlePredicate: func(error) bool { return false },
+		resultPredicate: predicate,
+	}
+}
+
 // Builder is used to build complex policies
 type Builder interface {
 	Retry(opts ...RetryOption) *RetryPolicy
 	WithCircuitBreaker(opts ...CircuitBreakerOption) *CircuitBreakerPolicy
+	Timeout(opts ...TimeoutOption) *TimeoutPolicy
+	Bulkhead(opts ...BulkheadOption) *BulkheadPolicy
+	Fallback(opts ...FallbackOption) *FallbackPolicy
+	OrResult(predicate ResultPredicate) Builder
 }
 
 // ErrorBuilder is used to build complex error policies
 type ErrorBuilder interface {
 	Builder
 	Or(errorObj interface{}) ErrorBuilder
+	OrAs(target interface{}) ErrorBuilder
+	OrIs(sentinel error) ErrorBuilder
 }
 
 type builder struct {
 	handlePredicate HandlePredicate
+	resultPredicate ResultPredicate
 }
 
 // HandlePredicate is used in the Handle function
 type HandlePredicate func(err error) bool
 
+// ResultPredicate is used in the HandleResult function
+type ResultPredicate func(val interface{}) bool
+
 // Or adds additional error types to the handled errors of the policy
 func (it *builder) Or(errorObj interface{}) ErrorBuilder {
+	return it.or(func(err error) bool { return reflect.TypeOf(err) == reflect.TypeOf(errorObj) })
+}
+
+// OrAs adds errors matching the target according to errors.As to the handled errors of the policy
+func (it *builder) OrAs(target interface{}) ErrorBuilder {
+	return it.or(errorAsPredicate(target))
+}
+
+// OrIs adds errors matching the sentinel according to errors.Is to the handled errors of the policy
+func (it *builder) OrIs(sentinel error) ErrorBuilder {
+	return it.or(func(err error) bool { return errors.Is(err, sentinel) })
+}
+
+func (it *builder) or(predicate HandlePredicate) ErrorBuilder {
 	pred := it.handlePredicate
 	return &builder{
 		handlePredicate: func(err error) bool {
-			if reflect.TypeOf(err) == reflect.TypeOf(errorObj) {
+			if predicate(err) {
 				return true
 			}
 			return pred(err)
 		},
+		resultPredicate: it.resultPredicate,
+	}
+}
+
+// errorAsPredicate handles errors errors.As finds a match of the target's type for in their chain
+// A fresh target is used for every evaluation, so the predicate is safe for concurrent use
+func errorAsPredicate(target interface{}) HandlePredicate {
+	targetType := reflect.TypeOf(target)
+	if targetType == nil || targetType.Kind() != reflect.Ptr {
+		panic("policy: target must be a non-nil pointer")
+	}
+	if elem := targetType.Elem(); elem.Kind() != reflect.Interface && !elem.Implements(errorType) {
+		panic("policy: *target must be interface or implement error")
+	}
+
+	return func(err error) bool {
+		return err != nil && errors.As(err, reflect.New(targetType.Elem()).Interface())
+	}
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// OrResult adds additional results to the handled results of the policy
+func (it *builder) OrResult(predicate ResultPredicate) Builder {
+	pred := it.resultPredicate
+	return &builder{
+		handlePredicate: it.handlePredicate,
+		resultPredicate: func(val interface{}) bool {
+			if predicate(val) {
+				return true
+			}
+			return pred != nil && pred(val)
+		},
 	}
 }
 
+// applyTo hands the handled errors and results over to the given policy
+func (it *builder) applyTo(plcy *BasePolicy) {
+	plcy.ShouldHandle = it.handlePredicate
+	plcy.ShouldHandleResult = it.resultPredicate
+}
+
 // Retry creates a RetryPolicy
 func (it *builder) Retry(opts ...RetryOption) *RetryPolicy {
 	plcy := DefaultRetryPolicy()
-	plcy.BasePolicy = BasePolicy{ShouldHandle: it.handlePredicate}
+	it.applyTo(&plcy.BasePolicy)
 
 	for _, opt := range opts {
 		opt(plcy)
@@ -91,6 +180,20 @@ func WithSleepDurationProvider(provider SleepDurationProvider) RetryOption {
 	}
 }
 
+// WithDelaySleepDurationProvider sets the DelaySleepDurationProvider, taking precedence over the SleepDurationProvider
+func WithDelaySleepDurationProvider(provider DelaySleepDurationProvider) RetryOption {
+	return func(o *RetryPolicy) {
+		o.DelaySleepDurationProvider = provider
+	}
+}
+
+// WithOutcomeSleepDurationProvider sets the OutcomeSleepDurationProvider, taking precedence over the SleepDurationProvider
+func WithOutcomeSleepDurationProvider(provider OutcomeSleepDurationProvider) RetryOption {
+	return func(o *RetryPolicy) {
+		o.OutcomeSleepDurationProvider = provider
+	}
+}
+
 // WithRetries sets retries
 func WithRetries(retries int) RetryOption {
 	return func(o *RetryPolicy) {
@@ -98,6 +201,14 @@ func WithRetries(retries int) RetryOption {
 	}
 }
 
+// WithAttemptTimeout bounds every attempt by a timeout of its own
+// Attempts failing because of their timeout are retried no matter the handled errors
+func WithAttemptTimeout(timeout time.Duration) RetryOption {
+	return func(o *RetryPolicy) {
+		o.AttemptTimeout = timeout
+	}
+}
+
 // WithCallback sets on retry callback
 func WithCallback(callback OnRetryCallback) RetryOption {
 	return func(o *RetryPolicy) {
@@ -112,10 +223,25 @@ func WithPredicates(predicates ...RetryPredicate) RetryOption {
 	}
 }
 
+// WithRetryClock sets the Clock the retries' sleeps are based on
+// A nil Clock falls back to the SystemClock
+func WithRetryClock(clock Clock) RetryOption {
+	return func(o *RetryPolicy) {
+		o.Clock = clock
+	}
+}
+
+// WithRetryListener adds a Listener notified about the retries' lifecycle events
+func WithRetryListener(listener Listener) RetryOption {
+	return func(o *RetryPolicy) {
+		o.Listeners = append(o.Listeners, listener)
+	}
+}
+
 // WithCircuitBreaker creates a CircuitBreakerPolicy
 func (it *builder) WithCircuitBreaker(opts ...CircuitBreakerOption) *CircuitBreakerPolicy {
 	plcy := DefaultCircuitBreakerPolicy()
-	plcy.BasePolicy = BasePolicy{ShouldHandle: it.handlePredicate}
+	it.applyTo(&plcy.BasePolicy)
 
 	for _, opt := range opts {
 		opt(plcy)
@@ -124,6 +250,45 @@ func (it *builder) WithCircuitBreaker(opts ...CircuitBreakerOption) *CircuitBrea
 	return plcy
 }
 
+// WithCircuitBreakerClock sets the Clock the circuit breaker's timing is based on
+// A nil Clock falls back to the SystemClock
+func WithCircuitBreakerClock(clock Clock) CircuitBreakerOption {
+	return func(o *CircuitBreakerPolicy) {
+		o.Clock = clock
+	}
+}
+
+// WithBrokenForOutcomeProvider sets the OutcomeSleepDurationProvider telling how long to keep the circuit broken for
+// It takes precedence over the BrokenForProvider
+func WithBrokenForOutcomeProvider(provider OutcomeSleepDurationProvider) CircuitBreakerOption {
+	return func(o *CircuitBreakerPolicy) {
+		o.BrokenForOutcomeProvider = provider
+	}
+}
+
+// WithFailureRate makes the circuit break once the ratio of handled errors within the sampling duration
+// reaches the given threshold, as soon as at least minimumThroughput executions were sampled
+// The threshold is inclusive, so a threshold of 1 breaks the circuit once every sampled execution failed.
+// It replaces breaking after MaxErrors consecutive errors and panics on a threshold outside (0, 1],
+// a non-positive sampling duration or a minimumThroughput below 1.
+func WithFailureRate(threshold float64, samplingDuration time.Duration, minimumThroughput int) CircuitBreakerOption {
+	if threshold <= 0 || threshold > 1 {
+		panic("policy: failure threshold must be greater than 0 and at most 1")
+	}
+	if samplingDuration <= 0 {
+		panic("policy: sampling duration must be positive")
+	}
+	if minimumThroughput < 1 {
+		panic("policy: minimum throughput must be at least 1")
+	}
+
+	return func(o *CircuitBreakerPolicy) {
+		o.FailureThreshold = threshold
+		o.SamplingDuration = samplingDuration
+		o.MinimumThroughput = minimumThroughput
+	}
+}
+
 // WithBrokenForProvider sets the SleepDurationProvider telling how long to keep the circuit broken for
 func WithBrokenForProvider(provider SleepDurationProvider) CircuitBreakerOption {
 	return func(o *CircuitBreakerPolicy) {
@@ -138,6 +303,21 @@ func WithOnBreakCallback(callback OnBreakCallback) CircuitBreakerOption {
 	}
 }
 
+// WithHalfOpenTrials sets the number of trial executions let through a half-open circuit
+// The circuit is closed once all of them succeeded, values below 1 let a single trial through
+func WithHalfOpenTrials(trials int) CircuitBreakerOption {
+	return func(o *CircuitBreakerPolicy) {
+		o.HalfOpenTrials = trials
+	}
+}
+
+// WithOnHalfOpenCallback sets the callback to be called whenever the circuit becomes half-open
+func WithOnHalfOpenCallback(callback func()) CircuitBreakerOption {
+	return func(o *CircuitBreakerPolicy) {
+		o.OnHalfOpen = callback
+	}
+}
+
 // WithOnResetCallback sets the callback to be called whenever the circuit is reset
 func WithOnResetCallback(callback func()) CircuitBreakerOption {
 	return func(o *CircuitBreakerPolicy) {
@@ -145,8 +325,139 @@ func WithOnResetCallback(callback func()) CircuitBreakerOption {
 	}
 }
 
+// WithCircuitBreakerListener adds a Listener notified about the circuit's lifecycle events
+func WithCircuitBreakerListener(listener Listener) CircuitBreakerOption {
+	return func(o *CircuitBreakerPolicy) {
+		o.Listeners = append(o.Listeners, listener)
+	}
+}
+
 // OnBreakCallback is the callback to be called whenever the circuit is broken
 type OnBreakCallback func(error, time.Duration)
 
 // CircuitBreakerOption modifies the CircuitBreakerPolicy
 type CircuitBreakerOption func(*CircuitBreakerPolicy)
+
+// Timeout creates a TimeoutPolicy
+func (it *builder) Timeout(opts ...TimeoutOption) *TimeoutPolicy {
+	plcy := DefaultTimeoutPolicy()
+	it.applyTo(&plcy.BasePolicy)
+
+	for _, opt := range opts {
+		opt(plcy)
+	}
+
+	return plcy
+}
+
+// WithTimeout sets the duration an execution may take at most
+func WithTimeout(timeout time.Duration) TimeoutOption {
+	return func(o *TimeoutPolicy) {
+		o.Timeout = timeout
+	}
+}
+
+// WithTimeoutClock sets the Clock the timeout elapses on
+// A nil Clock falls back to the SystemClock
+func WithTimeoutClock(clock Clock) TimeoutOption {
+	return func(o *TimeoutPolicy) {
+		o.Clock = clock
+	}
+}
+
+// WithTimeoutStrategy sets the strategy used to enforce the timeout
+func WithTimeoutStrategy(strategy TimeoutStrategy) TimeoutOption {
+	return func(o *TimeoutPolicy) {
+		o.Strategy = strategy
+	}
+}
+
+// WithTimeoutListener adds a Listener notified about executions timing out
+func WithTimeoutListener(listener Listener) TimeoutOption {
+	return func(o *TimeoutPolicy) {
+		o.Listeners = append(o.Listeners, listener)
+	}
+}
+
+// Bulkhead creates a BulkheadPolicy
+func (it *builder) Bulkhead(opts ...BulkheadOption) *BulkheadPolicy {
+	plcy := DefaultBulkheadPolicy()
+	it.applyTo(&plcy.BasePolicy)
+
+	for _, opt := range opts {
+		opt(plcy)
+	}
+
+	return plcy
+}
+
+// WithMaxParallelism sets the number of executions allowed to run concurrently
+// Values below 1 allow a single execution at a time
+func WithMaxParallelism(maxParallelism int) BulkheadOption {
+	return func(o *BulkheadPolicy) {
+		o.MaxParallelism = maxParallelism
+	}
+}
+
+// WithMaxQueue sets the number of executions allowed to wait for a free execution slot
+// Negative values disable the queue
+func WithMaxQueue(maxQueue int) BulkheadOption {
+	return func(o *BulkheadPolicy) {
+		o.MaxQueue = maxQueue
+	}
+}
+
+// WithQueueTimeout sets how long an execution waits for a free execution slot at most
+// Without a queue timeout queued executions wait until their context is done
+func WithQueueTimeout(timeout time.Duration) BulkheadOption {
+	return func(o *BulkheadPolicy) {
+		o.QueueTimeout = timeout
+	}
+}
+
+// WithBulkheadListener adds a Listener notified about rejected executions
+func WithBulkheadListener(listener Listener) BulkheadOption {
+	return func(o *BulkheadPolicy) {
+		o.Listeners = append(o.Listeners, listener)
+	}
+}
+
+// Fallback creates a FallbackPolicy
+func (it *builder) Fallback(opts ...FallbackOption) *FallbackPolicy {
+	plcy := DefaultFallbackPolicy()
+	it.applyTo(&plcy.BasePolicy)
+
+	for _, opt := range opts {
+		opt(plcy)
+	}
+
+	return plcy
+}
+
+// WithFallbackValue sets a static value returned instead of handled errors
+func WithFallbackValue(val interface{}) FallbackOption {
+	return func(o *FallbackPolicy) {
+		o.FallbackAction = func(context.Context, error) (interface{}, error) { return val, nil }
+	}
+}
+
+// WithFallbackAction sets the action called instead of returning handled errors
+func WithFallbackAction(action FallbackAction) FallbackOption {
+	return func(o *FallbackPolicy) {
+		o.FallbackAction = action
+	}
+}
+
+// WithOnFallbackCallback sets the callback to be called whenever the fallback is used
+func WithOnFallbackCallback(callback OnFallbackCallback) FallbackOption {
+	return func(o *FallbackPolicy) {
+		o.OnFallback = callback
+	}
+}
+
+// WithFallbackListener adds a Listener notified whenever the fallback is used
+func WithFallbackListener(listener Listener) FallbackOption {
+	return func(o *FallbackPolicy) {
+		o.Listeners = append(o.Listeners, listener)
+	}
+}
@@ -1,6 +1,8 @@
 package policy_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -23,6 +25,15 @@ func (AnotherCustomError) Error() string {
 	return ""
 }
 
+type PointerError struct {
+}
+
+func (*PointerError) Error() string {
+	return ""
+}
+
+var errSentinel = errors.New("sentinel")
+
 // handle
 
 func (test *PolicySuite) TestHandleSetsBasePolicy() {
@@ -61,6 +72,71 @@ func (test *PolicySuite) TestHandleAllHandlesAllKindsOfErrors() {
 	assert.True(test.T(), fn(fmt.Errorf("test")), "ShouldHandle returned false but correct error type was given")
 }
 
+func (test *PolicySuite) TestHandleResultHandlesOnlyGivenResults() {
+	plcy := policy.HandleResult(func(val interface{}) bool { return val == 503 }).Retry()
+
+	assert.False(test.T(), plcy.ShouldHandle(fmt.Errorf("test")), "ShouldHandle returned true for an error of a result policy")
+	assert.True(test.T(), plcy.ShouldHandleResult(503), "ShouldHandleResult returned false but handled result was given")
+	assert.False(test.T(), plcy.ShouldHandleResult(200), "ShouldHandleResult returned true but wrong result was given")
+}
+
+func (test *PolicySuite) TestAllGivenResultsAreHandledWithOrResultCascade() {
+	plcy := policy.HandleAll().
+		OrResult(func(val interface{}) bool { return val == 503 }).
+		OrResult(func(val interface{}) bool { return val == 429 }).
+		WithCircuitBreaker()
+
+	assert.True(test.T(), plcy.ShouldHandle(fmt.Errorf("test")), "ShouldHandle returned false but error was given")
+	assert.True(test.T(), plcy.ShouldHandleResult(503), "ShouldHandleResult returned false but handled result was given")
+	assert.True(test.T(), plcy.ShouldHandleResult(429), "ShouldHandleResult returned false but handled result was given")
+	assert.False(test.T(), plcy.ShouldHandleResult(200), "ShouldHandleResult returned true but wrong result was given")
+}
+
+func (test *PolicySuite) TestOrKeepsHandledResults() {
+	plcy := policy.HandleResult(func(val interface{}) bool { return val == 503 }).
+		Or(CustomError{}).
+		Fallback()
+
+	assert.True(test.T(), plcy.ShouldHandle(CustomError{}), "ShouldHandle returned false but correct error type was given")
+	assert.True(test.T(), plcy.ShouldHandleResult(503), "ShouldHandleResult returned false but handled result was given")
+}
+
+func (test *PolicySuite) TestHandleErrorAsHandlesWrappedErrors() {
+	fn := policy.HandleErrorAs(new(*PointerError)).
+		Retry().BasePolicy.ShouldHandle
+
+	assert.True(test.T(), fn(&PointerError{}), "ShouldHandle returned false but correct error type was given")
+	assert.True(test.T(), fn(fmt.Errorf("context: %w", &PointerError{})), "ShouldHandle returned false but wrapped error of correct type was given")
+	assert.False(test.T(), fn(fmt.Errorf("context: %w", CustomError{})), "ShouldHandle returned true but wrong error type was given")
+	assert.False(test.T(), fn(nil), "ShouldHandle returned true but no error was given")
+}
+
+func (test *PolicySuite) TestHandleErrorAsPanicsOnInvalidTarget() {
+	assert.Panics(test.T(), func() { policy.HandleErrorAs(PointerError{}) }, "non-pointer target accepted")
+	assert.Panics(test.T(), func() { policy.HandleErrorAs(new(string)) }, "target not implementing error accepted")
+}
+
+func (test *PolicySuite) TestHandleErrorIsHandlesWrappedSentinels() {
+	fn := policy.HandleErrorIs(errSentinel).
+		Retry().BasePolicy.ShouldHandle
+
+	assert.True(test.T(), fn(errSentinel), "ShouldHandle returned false but sentinel was given")
+	assert.True(test.T(), fn(fmt.Errorf("context: %w", errSentinel)), "ShouldHandle returned false but wrapped sentinel was given")
+	assert.False(test.T(), fn(fmt.Errorf("context")), "ShouldHandle returned true but other error was given")
+}
+
+func (test *PolicySuite) TestAllGivenErrorsAreHandledWithOrAsAndOrIs() {
+	fn := policy.HandleType(CustomError{}).
+		OrAs(new(*PointerError)).
+		OrIs(errSentinel).
+		Retry().BasePolicy.ShouldHandle
+
+	assert.True(test.T(), fn(CustomError{}), "ShouldHandle returned false but correct error type was given")
+	assert.True(test.T(), fn(fmt.Errorf("context: %w", &PointerError{})), "ShouldHandle returned false but wrapped error of correct type was given")
+	assert.True(test.T(), fn(fmt.Errorf("context: %w", errSentinel)), "ShouldHandle returned false but wrapped sentinel was given")
+	assert.False(test.T(), fn(AnotherCustomError{}), "ShouldHandle returned true but wrong error type was given")
+}
+
 // retry
 
 func (test *PolicySuite) TestRetryWithDurationsSetsSleepProviderAccordingly() {
@@ -138,3 +214,162 @@ func (test *PolicySuite) TestWithOnResetCallbackSetsOnReset() {
 
 	assert.Equal(test.T(), reflect.ValueOf(expectedFunc), reflect.ValueOf(plcy.OnReset), "policy's OnReset not set correctly")
 }
+
+// timeout
+
+func (test *PolicySuite) TestWithTimeoutSetsTimeout() {
+	expectedTimeout := time.Minute
+
+	plcy := policy.HandleAll().Timeout(policy.WithTimeout(expectedTimeout))
+
+	assert.Equal(test.T(), expectedTimeout, plcy.Timeout, "policy's Timeout not set correctly")
+}
+
+func (test *PolicySuite) TestWithTimeoutStrategySetsStrategy() {
+	plcy := policy.HandleAll().Timeout(policy.WithTimeoutStrategy(policy.Pessimistic))
+
+	assert.Equal(test.T(), policy.Pessimistic, plcy.Strategy, "policy's Strategy not set correctly")
+}
+
+func (test *PolicySuite) TestWithTimeoutClockSetsClock() {
+	clock := policy.SystemClock()
+
+	plcy := policy.HandleAll().Timeout(policy.WithTimeoutClock(clock))
+
+	assert.Equal(test.T(), clock, plcy.Clock, "policy's Clock not set correctly")
+}
+
+// bulkhead
+
+func (test *PolicySuite) TestWithMaxParallelismSetsMaxParallelism() {
+	plcy := policy.HandleAll().Bulkhead(policy.WithMaxParallelism(3))
+
+	assert.Equal(test.T(), 3, plcy.MaxParallelism, "policy's MaxParallelism not set correctly")
+}
+
+func (test *PolicySuite) TestWithMaxQueueSetsMaxQueue() {
+	plcy := policy.HandleAll().Bulkhead(policy.WithMaxQueue(5))
+
+	assert.Equal(test.T(), 5, plcy.MaxQueue, "policy's MaxQueue not set correctly")
+}
+
+func (test *PolicySuite) TestWithQueueTimeoutSetsQueueTimeout() {
+	plcy := policy.HandleAll().Bulkhead(policy.WithQueueTimeout(time.Second))
+
+	assert.Equal(test.T(), time.Second, plcy.QueueTimeout, "policy's QueueTimeout not set correctly")
+}
+
+// fallback
+
+func (test *PolicySuite) TestWithFallbackValueSetsFallbackAction() {
+	plcy := policy.HandleAll().Fallback(policy.WithFallbackValue("test"))
+
+	val, err := plcy.FallbackAction(context.Background(), fmt.Errorf("fail"))
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "test", val, "policy's FallbackAction does not return the given value")
+}
+
+func (test *PolicySuite) TestWithFallbackActionSetsFallbackAction() {
+	var expectedFunc policy.FallbackAction = func(context.Context, error) (interface{}, error) { return nil, nil }
+
+	plcy := policy.HandleAll().Fallback(policy.WithFallbackAction(expectedFunc))
+
+	assert.Equal(test.T(), reflect.ValueOf(expectedFunc), reflect.ValueOf(plcy.FallbackAction), "policy's FallbackAction not set correctly")
+}
+
+func (test *PolicySuite) TestWithOnFallbackCallbackSetsOnFallback() {
+	var expectedFunc policy.OnFallbackCallback = func(error) {}
+
+	plcy := policy.HandleAll().Fallback(policy.WithOnFallbackCallback(expectedFunc))
+
+	assert.Equal(test.T(), reflect.ValueOf(expectedFunc), reflect.ValueOf(plcy.OnFallback), "policy's OnFallback not set correctly")
+}
+
+// clock
+
+func (test *PolicySuite) TestWithRetryClockSetsClock() {
+	clock := policy.SystemClock()
+
+	plcy := policy.HandleAll().Retry(policy.WithRetryClock(clock))
+
+	assert.Equal(test.T(), clock, plcy.Clock, "policy's Clock not set correctly")
+}
+
+func (test *PolicySuite) TestWithCircuitBreakerClockSetsClock() {
+	clock := policy.SystemClock()
+
+	plcy := policy.HandleAll().WithCircuitBreaker(policy.WithCircuitBreakerClock(clock))
+
+	assert.Equal(test.T(), clock, plcy.Clock, "policy's Clock not set correctly")
+}
+
+func (test *PolicySuite) TestWithHalfOpenTrialsSetsHalfOpenTrials() {
+	plcy := policy.HandleAll().WithCircuitBreaker(policy.WithHalfOpenTrials(3))
+
+	assert.Equal(test.T(), 3, plcy.HalfOpenTrials, "policy's HalfOpenTrials not set correctly")
+}
+
+func (test *PolicySuite) TestWithOnHalfOpenCallbackSetsOnHalfOpen() {
+	var expectedFunc = func() {}
+
+	plcy := policy.HandleAll().WithCircuitBreaker(policy.WithOnHalfOpenCallback(expectedFunc))
+
+	assert.Equal(test.T(), reflect.ValueOf(expectedFunc), reflect.ValueOf(plcy.OnHalfOpen), "policy's OnHalfOpen not set correctly")
+}
+
+func (test *PolicySuite) TestWithFailureRateSetsFailureRateSettings() {
+	plcy := policy.HandleAll().WithCircuitBreaker(policy.WithFailureRate(0.5, time.Minute, 20))
+
+	assert.Equal(test.T(), 0.5, plcy.FailureThreshold, "policy's FailureThreshold not set correctly")
+	assert.Equal(test.T(), time.Minute, plcy.SamplingDuration, "policy's SamplingDuration not set correctly")
+	assert.Equal(test.T(), 20, plcy.MinimumThroughput, "policy's MinimumThroughput not set correctly")
+}
+
+func (test *PolicySuite) TestWithFailureRatePanicsOnInvalidSettings() {
+	assert.NotPanics(test.T(), func() { policy.WithFailureRate(1, time.Minute, 1) }, "valid settings rejected")
+	assert.Panics(test.T(), func() { policy.WithFailureRate(0, time.Minute, 20) }, "zero threshold accepted")
+	assert.Panics(test.T(), func() { policy.WithFailureRate(1.5, time.Minute, 20) }, "threshold above 1 accepted")
+	assert.Panics(test.T(), func() { policy.WithFailureRate(0.5, 0, 20) }, "zero sampling duration accepted")
+	assert.Panics(test.T(), func() { policy.WithFailureRate(0.5, time.Minute, 0) }, "zero minimum throughput accepted")
+}
+
+// outcome sleep duration providers
+
+func (test *PolicySuite) TestWithDelaySleepDurationProviderSetsProvider() {
+	var expectedFunc policy.DelaySleepDurationProvider = func(int, time.Duration) (time.Duration, bool) { return time.Second, true }
+
+	plcy := policy.HandleAll().Retry(policy.WithDelaySleepDurationProvider(expectedFunc))
+
+	assert.Equal(test.T(), reflect.ValueOf(expectedFunc), reflect.ValueOf(plcy.DelaySleepDurationProvider), "policy's DelaySleepDurationProvider not set correctly")
+}
+
+func (test *PolicySuite) TestWithOutcomeSleepDurationProviderSetsProvider() {
+	var expectedFunc policy.OutcomeSleepDurationProvider = func(int, error, interface{}) (time.Duration, bool) { return time.Second, true }
+
+	plcy := policy.HandleAll().Retry(policy.WithOutcomeSleepDurationProvider(expectedFunc))
+
+	assert.Equal(test.T(), reflect.ValueOf(expectedFunc), reflect.ValueOf(plcy.OutcomeSleepDurationProvider), "policy's OutcomeSleepDurationProvider not set correctly")
+}
+
+func (test *PolicySuite) TestWithBrokenForOutcomeProviderSetsProvider() {
+	var expectedFunc policy.OutcomeSleepDurationProvider = func(int, error, interface{}) (time.Duration, bool) { return time.Second, true }
+
+	plcy := policy.HandleAll().WithCircuitBreaker(policy.WithBrokenForOutcomeProvider(expectedFunc))
+
+	assert.Equal(test.T(), reflect.ValueOf(expectedFunc), reflect.ValueOf(plcy.BrokenForOutcomeProvider), "policy's BrokenForOutcomeProvider not set correctly")
+}
+
+func (test *PolicySuite) TestWithAttemptTimeoutSetsAttemptTimeout() {
+	plcy := policy.HandleAll().Retry(policy.WithAttemptTimeout(time.Second))
+
+	assert.Equal(test.T(), time.Second, plcy.AttemptTimeout, "policy's AttemptTimeout not set correctly")
+}
+
+func (test *PolicySuite) TestHandlesReportsHandledOutcomes() {
+	plcy := policy.HandleType(CustomError{}).OrResult(func(val interface{}) bool { return val == 503 }).Retry()
+
+	assert.True(test.T(), plcy.Handles(nil, CustomError{}), "handled error not reported")
+	assert.False(test.T(), plcy.Handles(nil, AnotherCustomError{}), "unhandled error reported")
+	assert.True(test.T(), plcy.Handles(503, nil), "handled result not reported")
+	assert.False(test.T(), plcy.Handles(200, nil), "unhandled result reported")
+}
@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BulkheadPolicy is a policy limiting the number of concurrent executions
+// A MaxParallelism below 1 allows a single execution at a time, a negative MaxQueue disables the queue.
+type BulkheadPolicy struct {
+	BasePolicy
+
+	MaxParallelism int
+	MaxQueue       int
+	QueueTimeout   time.Duration
+	Listeners      []Listener
+
+	initOnce sync.Once
+	slots    chan struct{}
+	admitted chan struct{}
+}
+
+// ExecuteVoid calls the given action and applies the policy
+func (it *BulkheadPolicy) ExecuteVoid(ctx context.Context, action func() error) error {
+	return it.ExecuteVoidContext(ctx, func(context.Context) error { return action() })
+}
+
+// Execute calls the given action and applies the policy
+func (it *BulkheadPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	return it.ExecuteContext(ctx, func(context.Context) (interface{}, error) { return action() })
+}
+
+// ExecuteVoidContext calls the given action and applies the policy
+func (it *BulkheadPolicy) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
+	if err := it.acquire(ctx); err != nil {
+		return err
+	}
+	defer it.release()
+
+	return action(ctx)
+}
+
+// ExecuteContext calls the given action and applies the policy
+func (it *BulkheadPolicy) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if err := it.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer it.release()
+
+	return action(ctx)
+}
+
+func (it *BulkheadPolicy) init() {
+	it.initOnce.Do(func() {
+		maxParallelism, maxQueue := it.MaxParallelism, it.MaxQueue
+		if maxParallelism < 1 {
+			maxParallelism = 1
+		}
+		if maxQueue < 0 {
+			maxQueue = 0
+		}
+
+		it.slots = make(chan struct{}, maxParallelism)
+		it.admitted = make(chan struct{}, maxParallelism+maxQueue)
+	})
+}
+
+func (it *BulkheadPolicy) acquire(ctx context.Context) error {
+	it.init()
+
+	select {
+	case it.admitted <- struct{}{}:
+	default:
+		return it.reject()
+	}
+
+	select {
+	case it.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if it.QueueTimeout > 0 {
+		timer := it.clock().NewTimer(it.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+
+	select {
+	case it.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		<-it.admitted
+		return ctx.Err()
+	case <-timeout:
+		<-it.admitted
+		return it.reject()
+	}
+}
+
+// reject notifies the listeners about a rejected execution and returns the BulkheadRejectedError
+func (it *BulkheadPolicy) reject() error {
+	err := BulkheadRejectedError{}
+	notify(it.Listeners, Event{Kind: CallRejected, Policy: it, At: it.clock().Now(), Err: err})
+	return err
+}
+
+func (it *BulkheadPolicy) release() {
+	<-it.slots
+	<-it.admitted
+}
+
+// BulkheadOption modifies the BulkheadPolicy
+type BulkheadOption func(*BulkheadPolicy)
+
+// BulkheadRejectedError signalizes that neither an execution slot nor a place in the queue was available
+type BulkheadRejectedError struct {
+}
+
+func (BulkheadRejectedError) Error() string {
+	return "bulkhead full"
+}
@@ -0,0 +1,154 @@
+package policy_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+func (test *PolicySuite) TestBulkheadReturnsActionResult() {
+	bulkhead := policy.DefaultBulkheadPolicy()
+	expectedErr := fmt.Errorf("fail")
+
+	val, err := bulkhead.Execute(context.Background(), func() (interface{}, error) { return "test", expectedErr })
+
+	assert.Equal(test.T(), expectedErr, err)
+	assert.Equal(test.T(), "test", val, "action's return value not returned")
+}
+
+func (test *PolicySuite) TestBulkheadRejectsWhenSlotsAndQueueAreFull() {
+	bulkhead := policy.DefaultBulkheadPolicy()
+	bulkhead.MaxParallelism = 1
+	bulkhead.MaxQueue = 0
+
+	release, done := occupyBulkhead(bulkhead, 1)
+	_, err := bulkhead.Execute(context.Background(), func() (interface{}, error) { return nil, nil })
+	close(release)
+	done.Wait()
+
+	assert.IsType(test.T(), policy.BulkheadRejectedError{}, err)
+}
+
+func (test *PolicySuite) TestBulkheadQueuesUntilSlotIsFree() {
+	bulkhead := policy.DefaultBulkheadPolicy()
+	bulkhead.MaxParallelism = 1
+	bulkhead.MaxQueue = 1
+
+	release, done := occupyBulkhead(bulkhead, 1)
+	go func() {
+		time.Sleep(time.Millisecond * 5)
+		close(release)
+	}()
+	err := bulkhead.ExecuteVoid(context.Background(), func() error { return nil })
+	done.Wait()
+
+	assert.Nil(test.T(), err, "queued execution not run after slot was freed")
+}
+
+func (test *PolicySuite) TestBulkheadRejectsAfterQueueTimeout() {
+	bulkhead := policy.DefaultBulkheadPolicy()
+	bulkhead.MaxParallelism = 1
+	bulkhead.MaxQueue = 1
+	bulkhead.QueueTimeout = time.Millisecond * 5
+
+	release, done := occupyBulkhead(bulkhead, 1)
+	err := bulkhead.ExecuteVoid(context.Background(), func() error { return nil })
+	close(release)
+	done.Wait()
+
+	assert.IsType(test.T(), policy.BulkheadRejectedError{}, err)
+}
+
+func (test *PolicySuite) TestBulkheadQueueHonoursContext() {
+	bulkhead := policy.DefaultBulkheadPolicy()
+	bulkhead.MaxParallelism = 1
+	bulkhead.MaxQueue = 1
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*5)
+	defer cancel()
+
+	release, done := occupyBulkhead(bulkhead, 1)
+	executeCalled := false
+	err := bulkhead.ExecuteVoid(ctx, func() error {
+		executeCalled = true
+		return nil
+	})
+	close(release)
+	done.Wait()
+
+	assert.Equal(test.T(), context.DeadlineExceeded, err)
+	assert.False(test.T(), executeCalled, "execute called although context was done")
+}
+
+func (test *PolicySuite) TestBulkheadFreesQueueAfterRejection() {
+	bulkhead := policy.DefaultBulkheadPolicy()
+	bulkhead.MaxParallelism = 1
+	bulkhead.MaxQueue = 1
+	bulkhead.QueueTimeout = time.Millisecond
+
+	release, done := occupyBulkhead(bulkhead, 1)
+	_ = bulkhead.ExecuteVoid(context.Background(), func() error { return nil })
+	close(release)
+	done.Wait()
+
+	err := bulkhead.ExecuteVoid(context.Background(), func() error { return nil })
+	assert.Nil(test.T(), err, "bulkhead did not free capacity")
+}
+
+func (test *PolicySuite) TestBulkheadRejectedError() {
+	err := policy.BulkheadRejectedError{}
+
+	assert.Equal(test.T(), "bulkhead full", err.Error())
+}
+
+// occupyBulkhead starts the given number of executions blocking until release is closed
+func occupyBulkhead(bulkhead *policy.BulkheadPolicy, executions int) (chan struct{}, *sync.WaitGroup) {
+	release := make(chan struct{})
+	started := sync.WaitGroup{}
+	done := &sync.WaitGroup{}
+
+	for i := 0; i < executions; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			_ = bulkhead.ExecuteVoid(context.Background(), func() error {
+				started.Done()
+				<-release
+				return nil
+			})
+		}()
+	}
+	started.Wait()
+
+	return release, done
+}
+
+func (test *PolicySuite) TestBulkheadAllowsSingleExecutionWithoutPositiveLimits() {
+	for _, maxParallelism := range []int{0, -1} {
+		bulkhead := policy.HandleAll().Bulkhead(policy.WithMaxParallelism(maxParallelism), policy.WithMaxQueue(-1))
+
+		release, done := occupyBulkhead(bulkhead, 1)
+		_, err := bulkhead.Execute(context.Background(), func() (interface{}, error) { return nil, nil })
+		close(release)
+		done.Wait()
+		afterRelease := bulkhead.ExecuteVoid(context.Background(), func() error { return nil })
+
+		assert.IsType(test.T(), policy.BulkheadRejectedError{}, err, "second execution not rejected for %d", maxParallelism)
+		assert.Nil(test.T(), afterRelease, "execution rejected after slot was freed for %d", maxParallelism)
+	}
+}
+
+func (test *PolicySuite) TestBulkheadWithoutClockUsesSystemClock() {
+	bulkhead := &policy.BulkheadPolicy{MaxParallelism: 1, MaxQueue: 1, QueueTimeout: time.Millisecond}
+
+	release, done := occupyBulkhead(bulkhead, 1)
+	err := bulkhead.ExecuteVoid(context.Background(), func() error { return nil })
+	close(release)
+	done.Wait()
+
+	assert.IsType(test.T(), policy.BulkheadRejectedError{}, err)
+}
@@ -2,6 +2,7 @@ package policy
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -10,92 +11,456 @@ import (
 type CircuitBreakerPolicy struct {
 	BasePolicy
 
-	MaxErrors int
-	//TODO error rate
-	BrokenForProvider SleepDurationProvider
-	OnBreak           OnBreakCallback
-	OnReset           func()
+	MaxErrors                int
+	FailureThreshold         float64
+	SamplingDuration         time.Duration
+	MinimumThroughput        int
+	BrokenForProvider        SleepDurationProvider
+	BrokenForOutcomeProvider OutcomeSleepDurationProvider
+	HalfOpenTrials           int
+	OnBreak                  OnBreakCallback
+	OnHalfOpen               func()
+	OnReset                  func()
+	Listeners                []Listener
 
 	consecutiveErrors int
 	mux               sync.Mutex
-	broken            bool
+	state             CircuitState
+	generation        uint64
+	lastBrokenAt      time.Time
+	trials            int
+	trialSuccesses    int
+	window            *samplingWindow
+	halfOpenTimer     Timer
+	subscribers       []chan CircuitStateChange
 }
 
 // ExecuteVoid calls the given action and applies the policy
 func (it *CircuitBreakerPolicy) ExecuteVoid(ctx context.Context, action func() error) error {
-	if it.broken {
-		return CircuitBrokenError{}
-	}
+	return it.ExecuteVoidContext(ctx, func(context.Context) error { return action() })
+}
 
-	err := action()
-	if err == nil {
-		it.mux.Lock()
-		it.consecutiveErrors = 0
-		it.mux.Unlock()
-		return err
-	}
+// Execute calls the given action and applies the policy
+func (it *CircuitBreakerPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	return it.ExecuteContext(ctx, func(context.Context) (interface{}, error) { return action() })
+}
 
-	if !it.ShouldHandle(err) {
+// ExecuteVoidContext calls the given action and applies the policy
+func (it *CircuitBreakerPolicy) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
+	generation, err := it.acquirePermission()
+	if err != nil {
+		it.notify(Event{Kind: CallRejected, Err: err})
 		return err
 	}
 
-	it.breakIfNecessary(err)
+	err = action(ctx)
+	it.record(err, generation)
 
 	return err
 }
 
-// Execute calls the given action and applies the policy
-func (it *CircuitBreakerPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
-	if it.broken {
-		return nil, CircuitBrokenError{}
+// ExecuteContext calls the given action and applies the policy
+func (it *CircuitBreakerPolicy) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	generation, err := it.acquirePermission()
+	if err != nil {
+		it.notify(Event{Kind: CallRejected, Err: err})
+		return nil, err
 	}
 
-	outcome, err := action()
-	if err == nil {
-		it.mux.Lock()
-		it.consecutiveErrors = 0
-		it.mux.Unlock()
-		return outcome, err
+	outcome, err := action(ctx)
+	if it.handlesResult(outcome, err) {
+		it.onHandledError(HandledResultError{Result: outcome}, generation)
+	} else {
+		it.record(err, generation)
 	}
 
-	if !it.ShouldHandle(err) {
-		return outcome, err
+	return outcome, err
+}
+
+// State returns the current state of the circuit
+func (it *CircuitBreakerPolicy) State() CircuitState {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return it.state
+}
+
+// ConsecutiveErrors returns the number of handled errors since the last success
+func (it *CircuitBreakerPolicy) ConsecutiveErrors() int {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return it.consecutiveErrors
+}
+
+// LastBrokenAt returns the time the circuit was broken or isolated the last time
+// It is the zero time if the circuit has never been broken
+func (it *CircuitBreakerPolicy) LastBrokenAt() time.Time {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return it.lastBrokenAt
+}
+
+// Subscribe returns a channel receiving every state change of the circuit and a function to unsubscribe again
+// Changes are dropped for subscribers not keeping up, so the circuit is never blocked by a subscriber
+func (it *CircuitBreakerPolicy) Subscribe() (<-chan CircuitStateChange, func()) {
+	changes := make(chan CircuitStateChange, subscriptionBuffer)
+
+	it.mux.Lock()
+	it.subscribers = append(it.subscribers, changes)
+	it.mux.Unlock()
+
+	once := sync.Once{}
+	unsubscribe := func() {
+		once.Do(func() {
+			it.mux.Lock()
+			defer it.mux.Unlock()
+
+			for i, subscriber := range it.subscribers {
+				if subscriber == changes {
+					it.subscribers = append(it.subscribers[:i], it.subscribers[i+1:]...)
+					break
+				}
+			}
+			close(changes)
+		})
 	}
 
-	it.breakIfNecessary(err)
+	return changes, unsubscribe
+}
 
-	return outcome, err
+// AddListener adds a Listener to a circuit breaker possibly serving executions already and returns a function to remove it again
+func (it *CircuitBreakerPolicy) AddListener(listener Listener) func() {
+	registered := &registeredListener{Listener: listener}
+
+	it.mux.Lock()
+	it.Listeners = append(it.Listeners[:len(it.Listeners):len(it.Listeners)], registered)
+	it.mux.Unlock()
+
+	once := sync.Once{}
+	remove := func() {
+		once.Do(func() {
+			it.mux.Lock()
+			defer it.mux.Unlock()
+
+			listeners := make([]Listener, 0, len(it.Listeners))
+			for _, l := range it.Listeners {
+				if l != Listener(registered) {
+					listeners = append(listeners, l)
+				}
+			}
+			it.Listeners = listeners
+		})
+	}
+
+	return remove
 }
 
-func (it *CircuitBreakerPolicy) resetAfter(duration time.Duration) {
-	time.Sleep(duration)
+// registeredListener gives a Listener added by AddListener an identity to remove it by
+type registeredListener struct {
+	Listener
+}
 
+// Isolate breaks the circuit until Reset is called
+// Executions are rejected with an IsolatedCircuitError meanwhile
+func (it *CircuitBreakerPolicy) Isolate() {
 	it.mux.Lock()
-	it.broken = false
-	it.consecutiveErrors = 0
+	it.stopHalfOpenTimer()
+	wasIsolated := it.state == CircuitIsolated
+	it.setState(CircuitIsolated)
+	it.mux.Unlock()
+
+	if !wasIsolated {
+		it.notify(Event{Kind: CircuitBroken, Err: IsolatedCircuitError{}})
+	}
+}
+
+// Reset closes the circuit no matter its current state and forgets all errors recorded so far
+func (it *CircuitBreakerPolicy) Reset() {
+	it.mux.Lock()
+	it.stopHalfOpenTimer()
+	wasClosed := it.state == CircuitClosed
+	it.close()
+	it.mux.Unlock()
+
+	if !wasClosed {
+		it.OnReset()
+		it.notify(Event{Kind: CircuitReset})
+	}
+}
+
+// acquirePermission checks whether an execution may pass the circuit and returns the generation admitting it
+// While half-open only HalfOpenTrials executions are let through
+func (it *CircuitBreakerPolicy) acquirePermission() (uint64, error) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	switch it.state {
+	case CircuitIsolated:
+		return 0, IsolatedCircuitError{}
+	case CircuitOpen:
+		return 0, CircuitBrokenError{}
+	case CircuitHalfOpen:
+		if it.trials >= it.halfOpenTrials() {
+			return 0, CircuitBrokenError{}
+		}
+		it.trials++
+	}
+
+	return it.generation, nil
+}
+
+// isStaleTrial reports whether an outcome must not count as a trial, as the execution was not admitted by the current half-open state
+// It must be called with the lock held
+func (it *CircuitBreakerPolicy) isStaleTrial(generation uint64) bool {
+	return it.state == CircuitHalfOpen && generation != it.generation
+}
+
+// halfOpenTrials returns the number of trial executions let through a half-open circuit, at least one
+func (it *CircuitBreakerPolicy) halfOpenTrials() int {
+	if it.HalfOpenTrials < 1 {
+		return 1
+	}
+	return it.HalfOpenTrials
+}
+
+// record counts the outcome of an execution admitted by the given generation
+func (it *CircuitBreakerPolicy) record(err error, generation uint64) {
+	switch {
+	case err == nil:
+		it.onSuccess(generation)
+	case !it.handlesError(err):
+		it.onUnhandledError(generation)
+	default:
+		it.onHandledError(err, generation)
+	}
+}
+
+func (it *CircuitBreakerPolicy) onSuccess(generation uint64) {
+	it.mux.Lock()
+
+	if it.isStaleTrial(generation) {
+		it.mux.Unlock()
+		return
+	}
+
+	if it.state != CircuitHalfOpen {
+		it.consecutiveErrors = 0
+		it.recordSample(false)
+		it.mux.Unlock()
+		return
+	}
+
+	it.trialSuccesses++
+	if it.trialSuccesses < it.halfOpenTrials() {
+		it.mux.Unlock()
+		return
+	}
+
+	it.close()
 	it.mux.Unlock()
 
 	it.OnReset()
+	it.notify(Event{Kind: CircuitReset})
 }
 
+// onUnhandledError gives back a trial permit, as unhandled errors tell nothing about the circuit's health
+func (it *CircuitBreakerPolicy) onUnhandledError(generation uint64) {
+	it.mux.Lock()
+	if it.state == CircuitHalfOpen && !it.isStaleTrial(generation) && it.trials > 0 {
+		it.trials--
+	}
+	it.mux.Unlock()
+}
+
+func (it *CircuitBreakerPolicy) onHandledError(err error, generation uint64) {
+	it.mux.Lock()
+
+	if it.isStaleTrial(generation) {
+		it.mux.Unlock()
+		return
+	}
+
+	switch it.state {
+	case CircuitHalfOpen:
+		it.consecutiveErrors++
+	case CircuitClosed:
+		it.consecutiveErrors++
+		it.recordSample(true)
+		if !it.shouldBreak() {
+			it.mux.Unlock()
+			return
+		}
+	default:
+		it.mux.Unlock()
+		return
+	}
+
+	it.breakCircuit(err)
+}
+
+// usesFailureRate reports whether the circuit is broken based on the failure rate within the sampling duration
+// instead of the number of consecutive errors
+func (it *CircuitBreakerPolicy) usesFailureRate() bool {
+	return it.SamplingDuration > 0
+}
+
+// shouldBreak must be called with the lock held
+// A failure rate equal to the FailureThreshold breaks the circuit
+func (it *CircuitBreakerPolicy) shouldBreak() bool {
+	if !it.usesFailureRate() {
+		return it.consecutiveErrors >= it.MaxErrors
+	}
+
+	throughput, failureRate := it.window.health(it.clock().Now())
+	return throughput >= it.MinimumThroughput && failureRate >= it.FailureThreshold
+}
+
+// recordSample must be called with the lock held
+func (it *CircuitBreakerPolicy) recordSample(failed bool) {
+	if !it.usesFailureRate() {
+		return
+	}
+
+	if it.window == nil || it.window.duration != it.SamplingDuration {
+		it.window = newSamplingWindow(it.SamplingDuration)
+	}
+	it.window.record(it.clock().Now(), failed)
+}
+
+// breakCircuit opens the circuit, it must be called with the lock held and releases it
 func (it *CircuitBreakerPolicy) breakCircuit(err error) {
-	it.broken = true
+	it.setState(CircuitOpen)
+	dur := it.brokenFor(err)
+	it.halfOpenTimer = it.clock().AfterFunc(dur, it.halfOpen)
+	it.mux.Unlock()
 
-	dur, _ := it.BrokenForProvider(it.consecutiveErrors)
 	it.OnBreak(err, dur)
+	it.notify(Event{Kind: CircuitBroken, Delay: dur, Err: err})
+}
+
+func (it *CircuitBreakerPolicy) brokenFor(err error) time.Duration {
+	if it.BrokenForOutcomeProvider == nil {
+		dur, _ := it.BrokenForProvider(it.consecutiveErrors)
+		return dur
+	}
+
+	var val interface{}
+	if handled, ok := err.(HandledResultError); ok {
+		val = handled.Result
+	}
+	dur, _ := it.BrokenForOutcomeProvider(it.consecutiveErrors, err, val)
+	return dur
+}
 
-	go it.resetAfter(dur)
+// close must be called with the lock held
+func (it *CircuitBreakerPolicy) close() {
+	it.setState(CircuitClosed)
+	it.consecutiveErrors = 0
+	it.trials = 0
+	it.trialSuccesses = 0
+	if it.window != nil {
+		it.window.reset()
+	}
 }
 
-func (it *CircuitBreakerPolicy) breakIfNecessary(err error) {
+// setState must be called with the lock held
+func (it *CircuitBreakerPolicy) setState(state CircuitState) {
+	if it.state == state {
+		return
+	}
+
+	change := CircuitStateChange{From: it.state, To: state, At: it.clock().Now()}
+	it.state = state
+	it.generation++
+	if state == CircuitOpen || state == CircuitIsolated {
+		it.lastBrokenAt = change.At
+	}
+
+	for _, subscriber := range it.subscribers {
+		select {
+		case subscriber <- change:
+		default:
+		}
+	}
+}
+
+// stopHalfOpenTimer must be called with the lock held
+func (it *CircuitBreakerPolicy) stopHalfOpenTimer() {
+	if it.halfOpenTimer != nil {
+		it.halfOpenTimer.Stop()
+		it.halfOpenTimer = nil
+	}
+}
+
+func (it *CircuitBreakerPolicy) halfOpen() {
 	it.mux.Lock()
-	it.consecutiveErrors++
-	if it.consecutiveErrors >= it.MaxErrors {
-		it.breakCircuit(err)
+	if it.state != CircuitOpen {
+		it.mux.Unlock()
+		return
 	}
+	it.setState(CircuitHalfOpen)
+	it.halfOpenTimer = nil
+	it.trials = 0
+	it.trialSuccesses = 0
 	it.mux.Unlock()
+
+	it.OnHalfOpen()
+	it.notify(Event{Kind: CircuitHalfOpened})
 }
 
+// notify hands the event to all listeners
+func (it *CircuitBreakerPolicy) notify(event Event) {
+	event.Policy = it
+	event.At = it.clock().Now()
+
+	it.mux.Lock()
+	listeners := it.Listeners
+	it.mux.Unlock()
+
+	notify(listeners, event)
+}
+
+// CircuitState is the state of a circuit
+type CircuitState int
+
+const (
+	// CircuitClosed lets all executions through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all executions, as the circuit has been broken
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of trial executions through
+	CircuitHalfOpen
+	// CircuitIsolated rejects all executions, as the circuit has been isolated manually
+	CircuitIsolated
+)
+
+func (it CircuitState) String() string {
+	switch it {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitIsolated:
+		return "isolated"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(it))
+	}
+}
+
+// CircuitStateChange describes a transition of a circuit from one state to another
+type CircuitStateChange struct {
+	From CircuitState
+	To   CircuitState
+	At   time.Time
+}
+
+// subscriptionBuffer is the number of state changes buffered for each subscriber
+const subscriptionBuffer = 16
+
 // CircuitBrokenError signalizes that the circuit is currently broken
 type CircuitBrokenError struct {
 }
@@ -103,3 +468,18 @@ type CircuitBrokenError struct {
 func (CircuitBrokenError) Error() string {
 	return "circuit broken"
 }
+
+// IsolatedCircuitError signalizes that the circuit was isolated manually
+// errors.Is reports it to be a CircuitBrokenError as well
+type IsolatedCircuitError struct {
+}
+
+func (IsolatedCircuitError) Error() string {
+	return "circuit isolated"
+}
+
+// Is makes errors.Is(err, CircuitBrokenError{}) hold for an IsolatedCircuitError
+func (IsolatedCircuitError) Is(target error) bool {
+	_, ok := target.(CircuitBrokenError)
+	return ok
+}
@@ -2,11 +2,13 @@ package policy_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/policytest"
 )
 
 func (test *PolicySuite) TestCBExecuteCalled() {
@@ -201,3 +203,425 @@ func (test *PolicySuite) TestCircuitOpenedAfterBrokenForDuration() {
 
 var defaultFailingAction = func() (interface{}, error) { return nil, fmt.Errorf("fail") }
 var defaultFailingVoidAction = func() error { return fmt.Errorf("fail") }
+
+func (test *PolicySuite) TestCircuitHalfOpensOnClock() {
+	halfOpenCalled := false
+	clock := policytest.NewFakeClock(time.Now())
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Clock = clock
+	circuitBreaker.MaxErrors = 0
+	circuitBreaker.OnHalfOpen = func() { halfOpenCalled = true }
+
+	_, _ = circuitBreaker.Execute(context.Background(), defaultFailingAction)
+	clock.Advance(time.Second)
+	_, err := circuitBreaker.Execute(context.Background(), defaultFailingAction)
+	assert.IsType(test.T(), policy.CircuitBrokenError{}, err)
+	assert.False(test.T(), halfOpenCalled, "circuit half-opened before broken duration elapsed")
+
+	clock.Advance(time.Second)
+	assert.True(test.T(), halfOpenCalled, "circuit not half-opened after broken duration elapsed on clock")
+}
+
+func (test *PolicySuite) TestCircuitBreakerWithoutClockUsesSystemClock() {
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker(policy.WithCircuitBreakerClock(nil))
+	circuitBreaker.MaxErrors = 0
+	circuitBreaker.BrokenForProvider = func(int) (time.Duration, bool) { return time.Millisecond, true }
+
+	_, _ = circuitBreaker.Execute(context.Background(), defaultFailingAction)
+	assert.Equal(test.T(), policy.CircuitOpen, circuitBreaker.State())
+
+	time.Sleep(time.Millisecond * 20)
+	assert.Equal(test.T(), policy.CircuitHalfOpen, circuitBreaker.State(), "circuit not half-opened without clock")
+}
+
+// half-open
+
+func (test *PolicySuite) TestHalfOpenCircuitLetsTrialsThrough() {
+	executeCalled := 0
+	circuitBreaker, _ := halfOpenCircuitBreaker(2)
+	execute := func() error {
+		executeCalled++
+		return nil
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = circuitBreaker.ExecuteVoid(context.Background(), func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	err := circuitBreaker.ExecuteVoid(context.Background(), execute)
+	assert.Nil(test.T(), err)
+	err = circuitBreaker.ExecuteVoid(context.Background(), execute)
+	assert.IsType(test.T(), policy.CircuitBrokenError{}, err, "more trials let through than configured")
+	close(release)
+
+	assert.Equal(test.T(), 1, executeCalled)
+}
+
+func (test *PolicySuite) TestHalfOpenCircuitClosesAfterTrialsSucceeded() {
+	resetCalled := 0
+	circuitBreaker, _ := halfOpenCircuitBreaker(2)
+	circuitBreaker.OnReset = func() { resetCalled++ }
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	assert.Equal(test.T(), 0, resetCalled, "circuit closed before all trials succeeded")
+	_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	assert.Equal(test.T(), 1, resetCalled, "circuit not closed after all trials succeeded")
+
+	for i := 0; i < 5; i++ {
+		err := circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+		assert.Nil(test.T(), err, "closed circuit rejected execution")
+	}
+}
+
+func (test *PolicySuite) TestHalfOpenCircuitBreaksOnHandledError() {
+	var brokenFor time.Duration
+	circuitBreaker, clock := halfOpenCircuitBreaker(3)
+	circuitBreaker.OnBreak = func(_ error, duration time.Duration) { brokenFor = duration }
+
+	err := circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	assert.NotNil(test.T(), err)
+	assert.Equal(test.T(), time.Second*2, brokenFor)
+
+	err = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	assert.IsType(test.T(), policy.CircuitBrokenError{}, err, "circuit not broken again after failed trial")
+
+	clock.Advance(brokenFor)
+	err = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	assert.Nil(test.T(), err, "circuit not half-opened again")
+}
+
+func (test *PolicySuite) TestHalfOpenCircuitIgnoresUnhandledErrors() {
+	expectedErr := fmt.Errorf("fail")
+	circuitBreaker, _ := halfOpenCircuitBreaker(1)
+	circuitBreaker.ShouldHandle = func(err error) bool { return err != expectedErr }
+
+	err := circuitBreaker.ExecuteVoid(context.Background(), func() error { return expectedErr })
+	assert.Equal(test.T(), expectedErr, err)
+
+	err = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	assert.Nil(test.T(), err, "unhandled error consumed the trial")
+}
+
+func (test *PolicySuite) TestHalfOpenCircuitLetsSingleTrialThroughWithoutTrials() {
+	for _, trials := range []int{0, -1} {
+		circuitBreaker, _ := halfOpenCircuitBreaker(trials)
+
+		err := circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+
+		assert.Nil(test.T(), err, "trial rejected for %d trials", trials)
+		assert.Equal(test.T(), policy.CircuitClosed, circuitBreaker.State(), "circuit not closed for %d trials", trials)
+	}
+}
+
+func (test *PolicySuite) TestHalfOpenCircuitIgnoresOutcomesOfCallsAdmittedBefore() {
+	for _, outcome := range []error{nil, fmt.Errorf("fail")} {
+		clock := policytest.NewFakeClock(time.Now())
+		circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+		circuitBreaker.Clock = clock
+		circuitBreaker.MaxErrors = 1
+
+		release := make(chan struct{})
+		started := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = circuitBreaker.ExecuteVoid(context.Background(), func() error {
+				close(started)
+				<-release
+				return outcome
+			})
+		}()
+		<-started
+		_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+		clock.Advance(time.Second * 2)
+		assert.Equal(test.T(), policy.CircuitHalfOpen, circuitBreaker.State())
+
+		close(release)
+		<-done
+
+		assert.Equal(test.T(), policy.CircuitHalfOpen, circuitBreaker.State(), "outcome %v of call admitted while closed counted as trial", outcome)
+		err := circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+		assert.Nil(test.T(), err, "trial rejected after outcome %v of call admitted while closed", outcome)
+		assert.Equal(test.T(), policy.CircuitClosed, circuitBreaker.State())
+	}
+}
+
+// halfOpenCircuitBreaker creates a circuit breaker half-opened after being broken for two seconds
+func halfOpenCircuitBreaker(trials int) (*policy.CircuitBreakerPolicy, *policytest.FakeClock) {
+	clock := policytest.NewFakeClock(time.Now())
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Clock = clock
+	circuitBreaker.MaxErrors = 1
+	circuitBreaker.HalfOpenTrials = trials
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	clock.Advance(time.Second * 2)
+
+	return circuitBreaker, clock
+}
+
+// failure rate
+
+func (test *PolicySuite) TestFailureRateCircuitBreaksWhenThresholdReached() {
+	circuitBreaker, _ := failureRateCircuitBreaker()
+
+	for i := 0; i < 10; i++ {
+		var action = func() error { return nil }
+		if i%2 == 0 {
+			action = defaultFailingVoidAction
+		}
+		err := circuitBreaker.ExecuteVoid(context.Background(), action)
+		assert.False(test.T(), isCircuitBroken(err), "circuit broken before minimum throughput was reached")
+	}
+
+	err := circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	assert.NotNil(test.T(), err)
+	err = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	assert.IsType(test.T(), policy.CircuitBrokenError{}, err, "circuit not broken after failure threshold was reached")
+}
+
+func (test *PolicySuite) TestFailureRateCircuitBreaksWhenRateEqualsThreshold() {
+	circuitBreaker, _ := failureRateCircuitBreaker()
+
+	for i := 0; i < 10; i++ {
+		var action = func() error { return nil }
+		if i >= 5 {
+			action = defaultFailingVoidAction
+		}
+		_ = circuitBreaker.ExecuteVoid(context.Background(), action)
+	}
+
+	assert.Equal(test.T(), policy.CircuitOpen, circuitBreaker.State(), "circuit not broken at a failure rate equal to the threshold")
+}
+
+func (test *PolicySuite) TestFailureRateCircuitIgnoresConsecutiveErrors() {
+	circuitBreaker, _ := failureRateCircuitBreaker()
+	circuitBreaker.MaxErrors = 1
+
+	for i := 0; i < 100; i++ {
+		var action = func() error { return nil }
+		if i%10 < 3 {
+			action = defaultFailingVoidAction
+		}
+		err := circuitBreaker.ExecuteVoid(context.Background(), action)
+		assert.False(test.T(), isCircuitBroken(err), "circuit broken below failure threshold")
+	}
+}
+
+func (test *PolicySuite) TestFailureRateCircuitForgetsSamplesOutsideWindow() {
+	circuitBreaker, clock := failureRateCircuitBreaker()
+
+	for i := 0; i < 9; i++ {
+		_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	}
+	clock.Advance(time.Minute * 2)
+
+	for i := 0; i < 9; i++ {
+		err := circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+		assert.Nil(test.T(), err)
+	}
+	err := circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	assert.NotNil(test.T(), err)
+	err = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	assert.Nil(test.T(), err, "circuit broken by samples outside the window")
+}
+
+func (test *PolicySuite) TestFailureRateCircuitWorksOnZeroTimeClock() {
+	clock := policytest.NewFakeClock(time.Time{})
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker(
+		policy.WithCircuitBreakerClock(clock),
+		policy.WithFailureRate(0.5, time.Minute, 10))
+
+	for i := 0; i < 10; i++ {
+		clock.Advance(time.Second * 3)
+		_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	}
+
+	assert.Equal(test.T(), policy.CircuitOpen, circuitBreaker.State(), "failures on a zero time clock not counted")
+}
+
+// failureRateCircuitBreaker creates a circuit breaker breaking at 50% failures within a minute after 10 executions
+func failureRateCircuitBreaker() (*policy.CircuitBreakerPolicy, *policytest.FakeClock) {
+	clock := policytest.NewFakeClock(time.Now())
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker(
+		policy.WithCircuitBreakerClock(clock),
+		policy.WithFailureRate(0.5, time.Minute, 10))
+
+	return circuitBreaker, clock
+}
+
+func isCircuitBroken(err error) bool {
+	_, ok := err.(policy.CircuitBrokenError)
+	return ok
+}
+
+// manual control
+
+func (test *PolicySuite) TestIsolatedCircuitRejectsExecutions() {
+	executeCalled := false
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+
+	circuitBreaker.Isolate()
+	err := circuitBreaker.ExecuteVoid(context.Background(), func() error {
+		executeCalled = true
+		return nil
+	})
+
+	assert.IsType(test.T(), policy.IsolatedCircuitError{}, err)
+	assert.True(test.T(), errors.Is(err, policy.CircuitBrokenError{}), "IsolatedCircuitError is no CircuitBrokenError")
+	assert.False(test.T(), executeCalled, "execute called on isolated circuit")
+}
+
+func (test *PolicySuite) TestIsolatedCircuitIsNotHalfOpenedAfterBrokenDuration() {
+	clock := policytest.NewFakeClock(time.Now())
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Clock = clock
+	circuitBreaker.MaxErrors = 1
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	circuitBreaker.Isolate()
+	clock.Advance(time.Hour)
+
+	err := circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	assert.IsType(test.T(), policy.IsolatedCircuitError{}, err, "isolated circuit released by broken duration")
+}
+
+func (test *PolicySuite) TestResetClosesCircuit() {
+	resetCalled := false
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.MaxErrors = 2
+	circuitBreaker.OnReset = func() { resetCalled = true }
+
+	circuitBreaker.Isolate()
+	circuitBreaker.Reset()
+	assert.True(test.T(), resetCalled, "OnReset not called")
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	circuitBreaker.Reset()
+	err := circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	assert.False(test.T(), isCircuitBroken(err), "Reset did not clear consecutive errors")
+}
+
+func (test *PolicySuite) TestResetStopsPendingHalfOpen() {
+	halfOpenCalled := false
+	clock := policytest.NewFakeClock(time.Now())
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Clock = clock
+	circuitBreaker.OnHalfOpen = func() { halfOpenCalled = true }
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	circuitBreaker.Reset()
+
+	assert.Equal(test.T(), 0, clock.PendingTimers(), "half-open timer not stopped")
+	assert.False(test.T(), halfOpenCalled)
+}
+
+func (test *PolicySuite) TestIsolatedCircuitError() {
+	err := policy.IsolatedCircuitError{}
+
+	assert.Equal(test.T(), "circuit isolated", err.Error())
+}
+
+// inspection
+
+func (test *PolicySuite) TestStateReflectsCircuit() {
+	clock := policytest.NewFakeClock(time.Now())
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Clock = clock
+	assert.Equal(test.T(), policy.CircuitClosed, circuitBreaker.State())
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	assert.Equal(test.T(), policy.CircuitOpen, circuitBreaker.State())
+
+	clock.Advance(time.Second * 2)
+	assert.Equal(test.T(), policy.CircuitHalfOpen, circuitBreaker.State())
+
+	circuitBreaker.Isolate()
+	assert.Equal(test.T(), policy.CircuitIsolated, circuitBreaker.State())
+
+	circuitBreaker.Reset()
+	assert.Equal(test.T(), policy.CircuitClosed, circuitBreaker.State())
+}
+
+func (test *PolicySuite) TestCountersReflectCircuit() {
+	now := time.Now()
+	clock := policytest.NewFakeClock(now)
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Clock = clock
+	circuitBreaker.MaxErrors = 2
+	assert.True(test.T(), circuitBreaker.LastBrokenAt().IsZero(), "never broken circuit has last broken time")
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	assert.Equal(test.T(), 1, circuitBreaker.ConsecutiveErrors())
+
+	clock.Advance(time.Minute)
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	assert.Equal(test.T(), 2, circuitBreaker.ConsecutiveErrors())
+	assert.Equal(test.T(), now.Add(time.Minute), circuitBreaker.LastBrokenAt())
+}
+
+func (test *PolicySuite) TestSubscribeDeliversStateChanges() {
+	clock := policytest.NewFakeClock(time.Now())
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Clock = clock
+	changes, unsubscribe := circuitBreaker.Subscribe()
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	clock.Advance(time.Second * 2)
+	_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	unsubscribe()
+
+	transitions := []policy.CircuitState{}
+	for change := range changes {
+		transitions = append(transitions, change.To)
+	}
+	assert.Equal(test.T(), []policy.CircuitState{policy.CircuitOpen, policy.CircuitHalfOpen, policy.CircuitClosed}, transitions)
+}
+
+func (test *PolicySuite) TestUnsubscribedChannelReceivesNoChanges() {
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	changes, unsubscribe := circuitBreaker.Subscribe()
+
+	unsubscribe()
+	unsubscribe()
+	circuitBreaker.Isolate()
+
+	_, ok := <-changes
+	assert.False(test.T(), ok, "channel not closed on unsubscribe")
+}
+
+func (test *PolicySuite) TestCircuitStateString() {
+	assert.Equal(test.T(), "closed", policy.CircuitClosed.String())
+	assert.Equal(test.T(), "open", policy.CircuitOpen.String())
+	assert.Equal(test.T(), "half-open", policy.CircuitHalfOpen.String())
+	assert.Equal(test.T(), "isolated", policy.CircuitIsolated.String())
+}
+
+// results
+
+func (test *PolicySuite) TestCircuitBreaksOnHandledResult() {
+	var breakErr error
+	circuitBreaker := policy.HandleAll().
+		OrResult(func(val interface{}) bool { return val == 503 }).
+		WithCircuitBreaker(policy.WithOnBreakCallback(func(err error, _ time.Duration) { breakErr = err }))
+
+	val, err := circuitBreaker.Execute(context.Background(), func() (interface{}, error) { return 503, nil })
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), 503, val)
+
+	_, err = circuitBreaker.Execute(context.Background(), func() (interface{}, error) { return 200, nil })
+	assert.IsType(test.T(), policy.CircuitBrokenError{}, err, "circuit not broken by handled result")
+	assert.Equal(test.T(), policy.HandledResultError{Result: 503}, breakErr)
+}
+
+func (test *PolicySuite) TestHandledResultError() {
+	err := policy.HandledResultError{Result: 503}
+
+	assert.Equal(test.T(), "handled result: 503", err.Error())
+}
@@ -0,0 +1,122 @@
+package policy
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock provides the time all time-dependent policy behavior is based on
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer creates a Timer sending the current time on its channel after the given duration
+	NewTimer(d time.Duration) Timer
+	// AfterFunc calls f after the given duration
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer represents a single event created by a Clock
+type Timer interface {
+	// C returns the channel the time is sent on once the timer fired
+	C() <-chan time.Time
+	// Stop prevents the timer from firing and reports whether it was stopped before firing
+	Stop() bool
+}
+
+// SystemClock is the Clock backed by the time package
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct {
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{timer: time.NewTimer(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{timer: time.AfterFunc(d, f)}
+}
+
+// withTimeout derives a context done with context.DeadlineExceeded once the timeout elapsed on the given Clock
+// Only the SystemClock sets the context's deadline, as deadlines are wall-clock time other Clocks do not follow.
+func withTimeout(ctx context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(systemClock); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	timeoutCtx := newClockTimeoutContext(ctx)
+	timer := clock.AfterFunc(timeout, func() { timeoutCtx.cancel(context.DeadlineExceeded) })
+
+	return timeoutCtx, func() {
+		timer.Stop()
+		timeoutCtx.cancel(context.Canceled)
+	}
+}
+
+// clockTimeoutContext is a context timed out by a Clock
+// It is done on its own rather than through a context derived by context.WithCancel,
+// so contexts derived from it see context.DeadlineExceeded as well once it timed out, like under the SystemClock.
+type clockTimeoutContext struct {
+	context.Context
+
+	done chan struct{}
+	mux  sync.Mutex
+	err  error
+}
+
+func newClockTimeoutContext(parent context.Context) *clockTimeoutContext {
+	it := &clockTimeoutContext{Context: parent, done: make(chan struct{})}
+	if parent.Done() != nil {
+		go func() {
+			select {
+			case <-parent.Done():
+				it.cancel(parent.Err())
+			case <-it.done:
+			}
+		}()
+	}
+
+	return it
+}
+
+// cancel makes the context done with the given error, unless it is done already
+func (it *clockTimeoutContext) cancel(err error) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	if it.err != nil {
+		return
+	}
+	it.err = err
+	close(it.done)
+}
+
+func (it *clockTimeoutContext) Done() <-chan struct{} {
+	return it.done
+}
+
+func (it *clockTimeoutContext) Err() error {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return it.err
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (it systemTimer) C() <-chan time.Time {
+	return it.timer.C
+}
+
+func (it systemTimer) Stop() bool {
+	return it.timer.Stop()
+}
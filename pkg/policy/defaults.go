@@ -1,6 +1,7 @@
 package policy
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -8,9 +9,18 @@ import (
 // DefaultRetries is the default number of retries if not overriden by options
 const DefaultRetries = 1
 
+// DefaultHalfOpenTrials is the default number of trial executions of a half-open circuit if not overriden by options
+const DefaultHalfOpenTrials = 1
+
+// DefaultTimeout is the default timeout of a TimeoutPolicy if not overriden by options
+const DefaultTimeout = time.Second * 30
+
+// DefaultMaxParallelism is the default number of concurrent executions of a BulkheadPolicy if not overriden by options
+const DefaultMaxParallelism = 10
+
 // DefaultBasePolicy is the base all policies come by default with
 func DefaultBasePolicy() *BasePolicy {
-	return &BasePolicy{ShouldHandle: func(_ error) bool { return true }}
+	return &BasePolicy{ShouldHandle: func(_ error) bool { return true }, Clock: SystemClock()}
 }
 
 // DefaultRetryPolicy is the default RetryPolicy
@@ -30,10 +40,41 @@ func DefaultCircuitBreakerPolicy() *CircuitBreakerPolicy {
 		BasePolicy:        *DefaultBasePolicy(),
 		MaxErrors:         DefaultRetries,
 		BrokenForProvider: func(try int) (duration time.Duration, ok bool) { return time.Second * 2, true },
+		HalfOpenTrials:    DefaultHalfOpenTrials,
 		OnBreak:           func(error, time.Duration) {},
+		OnHalfOpen:        func() {},
 		OnReset:           func() {},
-		broken:            false,
+		state:             CircuitClosed,
 		consecutiveErrors: 0,
 		mux:               sync.Mutex{},
 	}
 }
+
+// DefaultTimeoutPolicy is the default TimeoutPolicy
+func DefaultTimeoutPolicy() *TimeoutPolicy {
+	return &TimeoutPolicy{
+		BasePolicy: *DefaultBasePolicy(),
+		Timeout:    DefaultTimeout,
+		Strategy:   Optimistic,
+	}
+}
+
+// DefaultBulkheadPolicy is the default BulkheadPolicy
+func DefaultBulkheadPolicy() *BulkheadPolicy {
+	return &BulkheadPolicy{
+		BasePolicy:     *DefaultBasePolicy(),
+		MaxParallelism: DefaultMaxParallelism,
+		MaxQueue:       0,
+		QueueTimeout:   0,
+	}
+}
+
+// DefaultFallbackPolicy is the default FallbackPolicy
+// It returns handled errors unchanged until a fallback action is configured
+func DefaultFallbackPolicy() *FallbackPolicy {
+	return &FallbackPolicy{
+		BasePolicy:     *DefaultBasePolicy(),
+		FallbackAction: func(_ context.Context, err error) (interface{}, error) { return nil, err },
+		OnFallback:     func(error) {},
+	}
+}
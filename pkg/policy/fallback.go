@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"context"
+	"errors"
+)
+
+// FallbackPolicy is a policy substituting the outcome of failed executions
+type FallbackPolicy struct {
+	BasePolicy
+
+	FallbackAction FallbackAction
+	OnFallback     OnFallbackCallback
+	Listeners      []Listener
+}
+
+// ExecuteVoid calls the given action and applies the policy
+func (it *FallbackPolicy) ExecuteVoid(ctx context.Context, action func() error) error {
+	return it.ExecuteVoidContext(ctx, func(context.Context) error { return action() })
+}
+
+// Execute calls the given action and applies the policy
+func (it *FallbackPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	return it.ExecuteContext(ctx, func(context.Context) (interface{}, error) { return action() })
+}
+
+// ExecuteVoidContext calls the given action and applies the policy
+func (it *FallbackPolicy) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
+	err := action(ctx)
+	if !it.shouldFallback(err) {
+		return err
+	}
+
+	it.OnFallback(err)
+	notify(it.Listeners, Event{Kind: FallbackUsed, Policy: it, At: it.clock().Now(), Err: err})
+	_, err = it.FallbackAction(ctx, err)
+
+	return err
+}
+
+// ExecuteContext calls the given action and applies the policy
+func (it *FallbackPolicy) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	outcome, err := action(ctx)
+	if it.handlesResult(outcome, err) {
+		err = HandledResultError{Result: outcome}
+	} else if !it.shouldFallback(err) {
+		return outcome, err
+	}
+
+	it.OnFallback(err)
+	notify(it.Listeners, Event{Kind: FallbackUsed, Policy: it, At: it.clock().Now(), Err: err})
+
+	return it.FallbackAction(ctx, err)
+}
+
+// shouldFallback reports whether the given error is handled
+// A broken circuit is always handled, so a CircuitBreakerPolicy can fall back to a degraded response,
+// even if its error is wrapped by an inner policy like a RetryPolicy
+func (it *FallbackPolicy) shouldFallback(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return errors.Is(err, CircuitBrokenError{}) || it.handlesError(err)
+}
+
+// FallbackAction provides the substitute outcome for the handled error
+type FallbackAction func(ctx context.Context, err error) (interface{}, error)
+
+// OnFallbackCallback is executed before the fallback action is called
+type OnFallbackCallback func(err error)
+
+// FallbackOption modifies the FallbackPolicy
+type FallbackOption func(*FallbackPolicy)
@@ -0,0 +1,130 @@
+package policy_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+func (test *PolicySuite) TestFallbackNotUsedWithoutError() {
+	fallbackCalled := false
+	fallback := policy.DefaultFallbackPolicy()
+	fallback.FallbackAction = func(context.Context, error) (interface{}, error) {
+		fallbackCalled = true
+		return nil, nil
+	}
+
+	val, err := fallback.Execute(context.Background(), func() (interface{}, error) { return "test", nil })
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "test", val)
+	assert.False(test.T(), fallbackCalled, "fallback called although action succeeded")
+}
+
+func (test *PolicySuite) TestFallbackUsedOnHandledError() {
+	expectedErr := fmt.Errorf("fail")
+	var receivedErr error
+	fallback := policy.HandleAll().Fallback(policy.WithFallbackAction(func(_ context.Context, err error) (interface{}, error) {
+		receivedErr = err
+		return "fallback", nil
+	}))
+
+	val, err := fallback.Execute(context.Background(), func() (interface{}, error) { return nil, expectedErr })
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "fallback", val, "fallback value not returned")
+	assert.Equal(test.T(), expectedErr, receivedErr, "fallback action did not receive the handled error")
+}
+
+func (test *PolicySuite) TestFallbackOnlyAppliedIfShouldHandle() {
+	expectedErr := fmt.Errorf("fail")
+	fallback := policy.HandleType(CustomError{}).Fallback(policy.WithFallbackValue("fallback"))
+
+	val, err := fallback.Execute(context.Background(), func() (interface{}, error) { return nil, expectedErr })
+	assert.Equal(test.T(), expectedErr, err)
+	assert.Nil(test.T(), val)
+
+	val, err = fallback.Execute(context.Background(), func() (interface{}, error) { return nil, CustomError{} })
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "fallback", val)
+}
+
+func (test *PolicySuite) TestFallbackHandlesBrokenCircuit() {
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.MaxErrors = 0
+	_, _ = circuitBreaker.Execute(context.Background(), defaultFailingAction)
+
+	fallback := policy.HandleType(CustomError{}).Fallback(policy.WithFallbackValue("degraded"))
+	val, err := fallback.Execute(context.Background(), func() (interface{}, error) {
+		return circuitBreaker.Execute(context.Background(), defaultFailingAction)
+	})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "degraded", val, "broken circuit did not fall back")
+}
+
+func (test *PolicySuite) TestFallbackHandlesBrokenCircuitWrappedByRetry() {
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Isolate()
+	fallback := policy.HandleType(CustomError{}).Fallback(policy.WithFallbackValue("degraded"))
+	wrap := policy.Wrap(fallback, policy.HandleAll().Retry(policy.WithRetries(1), policy.WithDurations(0)), circuitBreaker)
+
+	val, err := wrap.Execute(context.Background(), defaultFailingAction)
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "degraded", val, "broken circuit wrapped by retry did not fall back")
+}
+
+func (test *PolicySuite) TestOnFallbackCalledBeforeFallback() {
+	calls := []string{}
+	fallback := policy.HandleAll().Fallback(
+		policy.WithOnFallbackCallback(func(error) { calls = append(calls, "callback") }),
+		policy.WithFallbackAction(func(context.Context, error) (interface{}, error) {
+			calls = append(calls, "fallback")
+			return nil, nil
+		}))
+
+	err := fallback.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), []string{"callback", "fallback"}, calls)
+}
+
+func (test *PolicySuite) TestDefaultFallbackReturnsError() {
+	expectedErr := fmt.Errorf("fail")
+	fallback := policy.DefaultFallbackPolicy()
+
+	err := fallback.ExecuteVoid(context.Background(), func() error { return expectedErr })
+
+	assert.Equal(test.T(), expectedErr, err)
+}
+
+func (test *PolicySuite) TestFallbackHandlesIsolatedCircuit() {
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Isolate()
+
+	fallback := policy.HandleType(CustomError{}).Fallback(policy.WithFallbackValue("degraded"))
+	val, err := fallback.Execute(context.Background(), func() (interface{}, error) {
+		return circuitBreaker.Execute(context.Background(), defaultFailingAction)
+	})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "degraded", val, "isolated circuit did not fall back")
+}
+
+func (test *PolicySuite) TestFallbackUsedOnHandledResult() {
+	var receivedErr error
+	fallback := policy.HandleResult(func(val interface{}) bool { return val == 503 }).
+		Fallback(policy.WithFallbackAction(func(_ context.Context, err error) (interface{}, error) {
+			receivedErr = err
+			return 200, nil
+		}))
+
+	val, err := fallback.Execute(context.Background(), func() (interface{}, error) { return 503, nil })
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), 200, val, "fallback not used for handled result")
+	assert.Equal(test.T(), policy.HandledResultError{Result: 503}, receivedErr)
+}
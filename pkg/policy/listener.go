@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+)
+
+// Listener is notified about the lifecycle events of policies
+// Listeners are called synchronously, so they should return quickly
+type Listener interface {
+	OnEvent(event Event)
+}
+
+// ListenerFunc adapts a function to a Listener
+type ListenerFunc func(event Event)
+
+// OnEvent calls the function
+func (it ListenerFunc) OnEvent(event Event) {
+	it(event)
+}
+
+// EventKind tells which lifecycle event occurred
+type EventKind int
+
+const (
+	// AttemptStarted is emitted by a RetryPolicy before every attempt
+	AttemptStarted EventKind = iota
+	// AttemptFailed is emitted by a RetryPolicy for every attempt returning an error or a handled result
+	AttemptFailed
+	// RetryScheduled is emitted by a RetryPolicy before sleeping for the next attempt
+	RetryScheduled
+	// GaveUp is emitted by a RetryPolicy once no more attempts are made for a handled outcome
+	GaveUp
+	// CircuitBroken is emitted by a CircuitBreakerPolicy whenever the circuit is broken,
+	// with an IsolatedCircuitError as Err if it was isolated manually
+	CircuitBroken
+	// CircuitHalfOpened is emitted by a CircuitBreakerPolicy whenever the circuit becomes half-open
+	CircuitHalfOpened
+	// CircuitReset is emitted by a CircuitBreakerPolicy whenever the circuit is closed again
+	CircuitReset
+	// CallRejected is emitted by a CircuitBreakerPolicy or BulkheadPolicy for every execution it rejects,
+	// and by a TimeoutPolicy for every execution not finishing within its timeout
+	CallRejected
+	// FallbackUsed is emitted by a FallbackPolicy before the fallback action is called
+	FallbackUsed
+)
+
+func (it EventKind) String() string {
+	switch it {
+	case AttemptStarted:
+		return "attempt started"
+	case AttemptFailed:
+		return "attempt failed"
+	case RetryScheduled:
+		return "retry scheduled"
+	case GaveUp:
+		return "gave up"
+	case CircuitBroken:
+		return "circuit broken"
+	case CircuitHalfOpened:
+		return "circuit half-opened"
+	case CircuitReset:
+		return "circuit reset"
+	case CallRejected:
+		return "call rejected"
+	case FallbackUsed:
+		return "fallback used"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(it))
+	}
+}
+
+// Event describes a lifecycle event of a policy
+type Event struct {
+	Kind EventKind
+	// Policy is the policy emitting the event
+	Policy Policy
+	// At is the time the event occurred according to the policy's Clock
+	At time.Time
+	// Attempt is the number of the attempt the event is about, the scheduled one for RetryScheduled
+	Attempt int
+	// Delay is the time slept before the scheduled attempt for RetryScheduled,
+	// the time the circuit is broken for for CircuitBroken
+	Delay time.Duration
+	// Err is the error causing the event, a HandledResultError if a result was handled
+	Err error
+	// Result is the result of the failed attempt for AttemptFailed
+	Result interface{}
+}
+
+func notify(listeners []Listener, event Event) {
+	for _, listener := range listeners {
+		listener.OnEvent(event)
+	}
+}
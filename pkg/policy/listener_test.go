@@ -0,0 +1,185 @@
+package policy_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/policytest"
+)
+
+// eventRecorder is a Listener recording all events
+type eventRecorder struct {
+	events []policy.Event
+}
+
+func (it *eventRecorder) OnEvent(event policy.Event) {
+	it.events = append(it.events, event)
+}
+
+func (it *eventRecorder) kinds() []policy.EventKind {
+	kinds := []policy.EventKind{}
+	for _, event := range it.events {
+		kinds = append(kinds, event.Kind)
+	}
+	return kinds
+}
+
+func (test *PolicySuite) TestRetryNotifiesListeners() {
+	recorder := &eventRecorder{}
+	expectedErr := fmt.Errorf("fail")
+	retry := policy.HandleAll().Retry(policy.WithRetries(1), policy.WithDurations(time.Millisecond), policy.WithRetryListener(recorder))
+
+	err := retry.ExecuteVoid(context.Background(), func() error { return expectedErr })
+
+	assert.Equal(test.T(), []policy.EventKind{
+		policy.AttemptStarted, policy.AttemptFailed, policy.RetryScheduled,
+		policy.AttemptStarted, policy.AttemptFailed, policy.GaveUp,
+	}, recorder.kinds())
+	assert.Equal(test.T(), expectedErr, recorder.events[1].Err)
+	assert.Equal(test.T(), 2, recorder.events[2].Attempt, "scheduled attempt not reported")
+	assert.Equal(test.T(), time.Millisecond, recorder.events[2].Delay)
+	assert.Equal(test.T(), 2, recorder.events[3].Attempt)
+	assert.Equal(test.T(), err, recorder.events[5].Err, "giving up not reported with the returned error")
+	for _, event := range recorder.events {
+		assert.Equal(test.T(), retry, event.Policy, "emitting policy not reported")
+	}
+}
+
+func (test *PolicySuite) TestRetryNotifiesListenersAboutHandledResults() {
+	recorder := &eventRecorder{}
+	retry := policy.HandleResult(func(val interface{}) bool { return val == 503 }).
+		Retry(policy.WithRetries(0), policy.WithRetryListener(recorder))
+
+	_, _ = retry.Execute(context.Background(), func() (interface{}, error) { return 503, nil })
+
+	assert.Equal(test.T(), []policy.EventKind{policy.AttemptStarted, policy.AttemptFailed, policy.GaveUp}, recorder.kinds())
+	assert.Equal(test.T(), policy.HandledResultError{Result: 503}, recorder.events[1].Err)
+	assert.Equal(test.T(), 503, recorder.events[1].Result)
+}
+
+func (test *PolicySuite) TestRetryDoesNotGiveUpOnUnhandledErrors() {
+	recorder := &eventRecorder{}
+	retry := policy.HandleType(CustomError{}).Retry(policy.WithRetryListener(recorder))
+
+	_ = retry.ExecuteVoid(context.Background(), func() error { return AnotherCustomError{} })
+
+	assert.Equal(test.T(), []policy.EventKind{policy.AttemptStarted, policy.AttemptFailed}, recorder.kinds())
+}
+
+func (test *PolicySuite) TestCircuitBreakerNotifiesListeners() {
+	recorder := &eventRecorder{}
+	clock := policytest.NewFakeClock(time.Now())
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker(policy.WithCircuitBreakerClock(clock), policy.WithCircuitBreakerListener(recorder))
+	circuitBreaker.BrokenForProvider = func(int) (time.Duration, bool) { return time.Minute, true }
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	_ = circuitBreaker.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	clock.Advance(time.Minute)
+	_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+
+	assert.Equal(test.T(), []policy.EventKind{policy.CircuitBroken, policy.CallRejected, policy.CircuitHalfOpened, policy.CircuitReset}, recorder.kinds())
+	assert.Equal(test.T(), time.Minute, recorder.events[0].Delay, "broken duration not reported")
+	assert.Equal(test.T(), policy.CircuitBrokenError{}, recorder.events[1].Err)
+	assert.Equal(test.T(), clock.Now(), recorder.events[2].At)
+}
+
+func (test *PolicySuite) TestCircuitBreakerNotifiesListenersAboutManualReset() {
+	recorder := &eventRecorder{}
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker(policy.WithCircuitBreakerListener(recorder))
+
+	circuitBreaker.Reset()
+	circuitBreaker.Isolate()
+	_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	circuitBreaker.Reset()
+
+	assert.Equal(test.T(), []policy.EventKind{policy.CircuitBroken, policy.CallRejected, policy.CircuitReset}, recorder.kinds())
+	assert.Equal(test.T(), policy.IsolatedCircuitError{}, recorder.events[0].Err, "manual isolation not told apart")
+	assert.Equal(test.T(), policy.IsolatedCircuitError{}, recorder.events[1].Err)
+}
+
+func (test *PolicySuite) TestCircuitBreakerNotifiesAddedListenersUntilRemoved() {
+	recorder := &eventRecorder{}
+	other := &eventRecorder{}
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker(policy.WithCircuitBreakerListener(other))
+	circuitBreaker.Isolate()
+	circuitBreaker.Isolate()
+
+	remove := circuitBreaker.AddListener(recorder)
+	_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+	remove()
+	remove()
+	_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return nil })
+
+	assert.Equal(test.T(), []policy.EventKind{policy.CallRejected}, recorder.kinds(), "removed listener notified")
+	assert.Len(test.T(), circuitBreaker.Listeners, 1, "other listeners removed")
+	assert.Equal(test.T(), []policy.EventKind{policy.CircuitBroken, policy.CallRejected, policy.CallRejected}, other.kinds(), "isolating isolated circuit notified again")
+}
+
+func (test *PolicySuite) TestBulkheadNotifiesListenersAboutRejections() {
+	recorder := &eventRecorder{}
+	bulkhead := policy.HandleAll().Bulkhead(policy.WithMaxParallelism(1), policy.WithBulkheadListener(recorder))
+
+	release, done := occupyBulkhead(bulkhead, 1)
+	_ = bulkhead.ExecuteVoid(context.Background(), func() error { return nil })
+	close(release)
+	done.Wait()
+
+	assert.Equal(test.T(), []policy.EventKind{policy.CallRejected}, recorder.kinds())
+	assert.Equal(test.T(), policy.BulkheadRejectedError{}, recorder.events[0].Err)
+}
+
+func (test *PolicySuite) TestTimeoutNotifiesListenersAboutRejections() {
+	for _, strategy := range []policy.TimeoutStrategy{policy.Optimistic, policy.Pessimistic} {
+		recorder := &eventRecorder{}
+		timeout := policy.HandleAll().Timeout(policy.WithTimeout(time.Millisecond), policy.WithTimeoutStrategy(strategy), policy.WithTimeoutListener(recorder))
+
+		_ = timeout.ExecuteVoid(context.Background(), func() error { return nil })
+		_ = timeout.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		assert.Equal(test.T(), []policy.EventKind{policy.CallRejected}, recorder.kinds(), "strategy %v", strategy)
+		assert.Equal(test.T(), policy.TimeoutRejectedError{Timeout: time.Millisecond}, recorder.events[0].Err)
+	}
+}
+
+func (test *PolicySuite) TestFallbackNotifiesListeners() {
+	recorder := &eventRecorder{}
+	expectedErr := fmt.Errorf("fail")
+	fallback := policy.HandleAll().Fallback(policy.WithFallbackValue("fallback"), policy.WithFallbackListener(recorder))
+
+	_, _ = fallback.Execute(context.Background(), func() (interface{}, error) { return "test", nil })
+	_, _ = fallback.Execute(context.Background(), func() (interface{}, error) { return nil, expectedErr })
+
+	assert.Equal(test.T(), []policy.EventKind{policy.FallbackUsed}, recorder.kinds())
+	assert.Equal(test.T(), expectedErr, recorder.events[0].Err)
+}
+
+func (test *PolicySuite) TestAllListenersAreNotified() {
+	first, second := &eventRecorder{}, &eventRecorder{}
+	fallback := policy.HandleAll().Fallback(policy.WithFallbackListener(first), policy.WithFallbackListener(second))
+
+	_ = fallback.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+
+	assert.Len(test.T(), first.events, 1)
+	assert.Len(test.T(), second.events, 1)
+}
+
+func (test *PolicySuite) TestListenerFuncIsCalled() {
+	var received policy.Event
+	listener := policy.ListenerFunc(func(event policy.Event) { received = event })
+
+	listener.OnEvent(policy.Event{Kind: policy.GaveUp})
+
+	assert.Equal(test.T(), policy.GaveUp, received.Kind)
+}
+
+func (test *PolicySuite) TestEventKindString() {
+	assert.Equal(test.T(), "retry scheduled", policy.RetryScheduled.String())
+	assert.Equal(test.T(), "circuit half-opened", policy.CircuitHalfOpened.String())
+	assert.Equal(test.T(), "EventKind(42)", policy.EventKind(42).String())
+}
@@ -2,6 +2,8 @@ package policy
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -11,12 +13,75 @@ type Policy interface {
 	ExecuteVoid(ctx context.Context, action func() error) error
 	// Execute calls the given action and applies the policy
 	Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error)
+	// ExecuteVoidContext calls the given action handing it the execution's context and applies the policy
+	ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error
+	// ExecuteContext calls the given action handing it the execution's context and applies the policy
+	ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error)
+}
+
+// OutcomeHandler is implemented by policies telling which outcomes of an execution they handle
+type OutcomeHandler interface {
+	// Handles reports whether the policy handles the given outcome of an execution
+	Handles(val interface{}, err error) bool
 }
 
 // BasePolicy is the base, all policy types have in common
 type BasePolicy struct {
-	ShouldHandle HandlePredicate
+	ShouldHandle       HandlePredicate
+	ShouldHandleResult ResultPredicate
+	// Clock is the Clock time-dependent behavior is based on, the SystemClock if nil
+	Clock Clock
+}
+
+// Handles reports whether the policy handles the given outcome of an execution
+func (it *BasePolicy) Handles(val interface{}, err error) bool {
+	if err != nil {
+		return it.handlesError(err)
+	}
+	return it.handlesResult(val, err)
+}
+
+// handlesError reports whether the given error is handled
+// Results an inner policy gave up on, e.g. a RetryPolicy returning a RetryExhaustedError, are handled like the results themselves.
+func (it *BasePolicy) handlesError(err error) bool {
+	if it.ShouldHandle(err) {
+		return true
+	}
+
+	var handled HandledResultError
+	return it.ShouldHandleResult != nil && errors.As(err, &handled) && it.ShouldHandleResult(handled.Result)
+}
+
+// clock returns the policy's Clock, the SystemClock if none is set
+func (it *BasePolicy) clock() Clock {
+	if it.Clock == nil {
+		return SystemClock()
+	}
+	return it.Clock
+}
+
+// handlesResult reports whether the result of an execution not returning an error is handled
+func (it *BasePolicy) handlesResult(val interface{}, err error) bool {
+	return err == nil && it.ShouldHandleResult != nil && it.ShouldHandleResult(val)
 }
 
 // SleepDurationProvider provides the next sleep duration for the given try
 type SleepDurationProvider func(try int) (duration time.Duration, ok bool)
+
+// DelaySleepDurationProvider provides the next sleep duration for the given try based on the previous sleep duration
+// previous is zero before the first retry
+type DelaySleepDurationProvider func(try int, previous time.Duration) (duration time.Duration, ok bool)
+
+// OutcomeSleepDurationProvider provides the next sleep duration for the given try based on the failed execution's outcome
+// err is the handled error, a HandledResultError if a result was handled, and val the execution's result
+type OutcomeSleepDurationProvider func(try int, err error, val interface{}) (duration time.Duration, ok bool)
+
+// HandledResultError signalizes that a result was handled instead of an error
+// It is handed to callbacks and fallback actions expecting an error
+type HandledResultError struct {
+	Result interface{}
+}
+
+func (it HandledResultError) Error() string {
+	return fmt.Sprintf("handled result: %v", it.Result)
+}
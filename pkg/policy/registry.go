@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry stores policies by name, so all callers of a dependency share the same instances
+// It is safe for concurrent use, the zero value is an empty Registry ready to use.
+type Registry struct {
+	mux      sync.RWMutex
+	policies map[string]Policy
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{policies: map[string]Policy{}}
+}
+
+// Register stores the policy under the given name
+// It fails with a PolicyAlreadyRegisteredError if the name is taken.
+func (it *Registry) Register(name string, plcy Policy) error {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	if _, ok := it.policies[name]; ok {
+		return PolicyAlreadyRegisteredError{Name: name}
+	}
+	it.store(name, plcy)
+
+	return nil
+}
+
+// Get returns the policy stored under the given name
+func (it *Registry) Get(name string) (Policy, bool) {
+	it.mux.RLock()
+	defer it.mux.RUnlock()
+
+	plcy, ok := it.policies[name]
+	return plcy, ok
+}
+
+// GetOrAdd returns the policy stored under the given name
+// If the name is not taken yet, the policy created by the given factory is stored and returned.
+// The factory is called without holding the Registry's lock, so it may use the Registry itself.
+// Concurrent callers may each call the factory for the same name, all of them get the policy stored first.
+func (it *Registry) GetOrAdd(name string, factory func() Policy) Policy {
+	if plcy, ok := it.Get(name); ok {
+		return plcy
+	}
+
+	created := factory()
+
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	if plcy, ok := it.policies[name]; ok {
+		return plcy
+	}
+	it.store(name, created)
+
+	return created
+}
+
+// store must be called with the lock held
+func (it *Registry) store(name string, plcy Policy) {
+	if it.policies == nil {
+		it.policies = map[string]Policy{}
+	}
+	it.policies[name] = plcy
+}
+
+// Remove removes the policy stored under the given name and reports whether there was one
+func (it *Registry) Remove(name string) bool {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	_, ok := it.policies[name]
+	delete(it.policies, name)
+
+	return ok
+}
+
+// Names returns the names of all stored policies in order
+func (it *Registry) Names() []string {
+	it.mux.RLock()
+	defer it.mux.RUnlock()
+
+	names := make([]string, 0, len(it.policies))
+	for name := range it.policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// CircuitBreakers returns every circuit breaker among the stored policies and the policies wrapped or decorated by them
+// They are ordered by the name they are stored under, circuit breakers of the same PolicyWrap outermost first.
+func (it *Registry) CircuitBreakers() []RegisteredCircuitBreaker {
+	breakers := []RegisteredCircuitBreaker{}
+	for _, name := range it.Names() {
+		plcy, ok := it.Get(name)
+		if !ok {
+			continue
+		}
+		for _, breaker := range CircuitBreakers(plcy) {
+			breakers = append(breakers, RegisteredCircuitBreaker{Name: name, CircuitBreaker: breaker})
+		}
+	}
+
+	return breakers
+}
+
+// RegisteredCircuitBreaker is a circuit breaker found among the policies of a Registry
+type RegisteredCircuitBreaker struct {
+	// Name is the name of the policy the circuit breaker was found in
+	Name           string
+	CircuitBreaker *CircuitBreakerPolicy
+}
+
+// PolicyAlreadyRegisteredError signalizes that a policy is already stored under the name
+type PolicyAlreadyRegisteredError struct {
+	Name string
+}
+
+func (it PolicyAlreadyRegisteredError) Error() string {
+	return fmt.Sprintf("policy %q already registered", it.Name)
+}
@@ -0,0 +1,153 @@
+package policy_test
+
+import (
+	"sync"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+func (test *PolicySuite) TestRegistryReturnsRegisteredPolicies() {
+	registry := policy.NewRegistry()
+	retry := policy.DefaultRetryPolicy()
+
+	err := registry.Register("retry", retry)
+	plcy, ok := registry.Get("retry")
+
+	assert.Nil(test.T(), err)
+	assert.True(test.T(), ok)
+	assert.Equal(test.T(), retry, plcy)
+}
+
+func (test *PolicySuite) TestRegistryReportsMissingPolicies() {
+	plcy, ok := policy.NewRegistry().Get("missing")
+
+	assert.False(test.T(), ok)
+	assert.Nil(test.T(), plcy)
+}
+
+func (test *PolicySuite) TestRegistryRejectsTakenNames() {
+	registry := policy.NewRegistry()
+	retry := policy.DefaultRetryPolicy()
+	_ = registry.Register("retry", retry)
+
+	err := registry.Register("retry", policy.DefaultRetryPolicy())
+	plcy, _ := registry.Get("retry")
+
+	assert.Equal(test.T(), policy.PolicyAlreadyRegisteredError{Name: "retry"}, err)
+	assert.Equal(test.T(), `policy "retry" already registered`, err.Error())
+	assert.Equal(test.T(), retry, plcy, "registered policy replaced")
+}
+
+func (test *PolicySuite) TestRegistryGetOrAddCreatesPolicyOnce() {
+	registry := policy.NewRegistry()
+	factoryCalls := 0
+	factory := func() policy.Policy {
+		factoryCalls++
+		return policy.DefaultCircuitBreakerPolicy()
+	}
+
+	first := registry.GetOrAdd("circuit", factory)
+	second := registry.GetOrAdd("circuit", factory)
+
+	assert.Equal(test.T(), 1, factoryCalls, "factory called for existing policy")
+	assert.True(test.T(), first == second, "callers do not share the same instance")
+}
+
+func (test *PolicySuite) TestZeroRegistryIsReadyToUse() {
+	registry := policy.Registry{}
+	retry := policy.DefaultRetryPolicy()
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+
+	assert.Nil(test.T(), registry.Register("retry", retry))
+	assert.Equal(test.T(), circuitBreaker, (&policy.Registry{}).GetOrAdd("circuit", func() policy.Policy { return circuitBreaker }))
+	assert.Equal(test.T(), []string{"retry"}, registry.Names())
+}
+
+func (test *PolicySuite) TestRegistryGetOrAddFactoryMayUseRegistry() {
+	registry := policy.NewRegistry()
+	shared := policy.DefaultCircuitBreakerPolicy()
+
+	plcy := registry.GetOrAdd("payments", func() policy.Policy {
+		circuitBreaker := registry.GetOrAdd("circuit", func() policy.Policy { return shared })
+		return policy.Wrap(policy.DefaultRetryPolicy(), circuitBreaker)
+	})
+
+	assert.NotNil(test.T(), plcy)
+	assert.Equal(test.T(), []policy.RegisteredCircuitBreaker{
+		{Name: "circuit", CircuitBreaker: shared},
+		{Name: "payments", CircuitBreaker: shared},
+	}, registry.CircuitBreakers())
+}
+
+func (test *PolicySuite) TestRegistryGetOrAddIsSafeForConcurrentUse() {
+	registry := policy.NewRegistry()
+	policies := make([]policy.Policy, 50)
+	wg := sync.WaitGroup{}
+
+	for i := range policies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			policies[i] = registry.GetOrAdd("circuit", func() policy.Policy { return policy.DefaultCircuitBreakerPolicy() })
+		}(i)
+	}
+	wg.Wait()
+
+	for _, plcy := range policies {
+		assert.True(test.T(), plcy == policies[0], "concurrent callers do not share the same instance")
+	}
+}
+
+func (test *PolicySuite) TestRegistryRemovesPolicies() {
+	registry := policy.NewRegistry()
+	_ = registry.Register("retry", policy.DefaultRetryPolicy())
+
+	assert.True(test.T(), registry.Remove("retry"))
+	assert.False(test.T(), registry.Remove("retry"), "removing missing policy reported")
+	_, ok := registry.Get("retry")
+	assert.False(test.T(), ok, "policy not removed")
+	assert.Nil(test.T(), registry.Register("retry", policy.DefaultRetryPolicy()), "name not freed")
+}
+
+func (test *PolicySuite) TestRegistryNamesAreOrdered() {
+	registry := policy.NewRegistry()
+	_ = registry.Register("b", policy.DefaultRetryPolicy())
+	_ = registry.Register("a", policy.DefaultRetryPolicy())
+
+	assert.Equal(test.T(), []string{"a", "b"}, registry.Names())
+}
+
+func (test *PolicySuite) TestRegistryEnumeratesCircuitBreakers() {
+	registry := policy.NewRegistry()
+	direct := policy.DefaultCircuitBreakerPolicy()
+	outer := policy.DefaultCircuitBreakerPolicy()
+	inner := policy.DefaultCircuitBreakerPolicy()
+	direct.MaxErrors, outer.MaxErrors, inner.MaxErrors = 1, 2, 3
+	_ = registry.Register("payments", policy.Wrap(policy.DefaultRetryPolicy(), outer, inner))
+	_ = registry.Register("retry", policy.DefaultRetryPolicy())
+	_ = registry.Register("auth", direct)
+
+	assert.Equal(test.T(), []policy.RegisteredCircuitBreaker{
+		{Name: "auth", CircuitBreaker: direct},
+		{Name: "payments", CircuitBreaker: outer},
+		{Name: "payments", CircuitBreaker: inner},
+	}, registry.CircuitBreakers())
+}
+
+func (test *PolicySuite) TestRegistryEnumeratesDecoratedCircuitBreakers() {
+	registry := policy.NewRegistry()
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	_ = registry.Register("payments", decorator{Policy: policy.Wrap(policy.DefaultRetryPolicy(), decorator{Policy: circuitBreaker})})
+
+	assert.Equal(test.T(), []policy.RegisteredCircuitBreaker{{Name: "payments", CircuitBreaker: circuitBreaker}}, registry.CircuitBreakers())
+}
+
+// decorator applies the embedded policy unchanged
+type decorator struct {
+	policy.Policy
+}
+
+func (it decorator) Unwrap() policy.Policy {
+	return it.Policy
+}
@@ -2,6 +2,7 @@ package policy
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -9,50 +10,101 @@ import (
 type RetryPolicy struct {
 	BasePolicy
 
-	ExpectedRetries       int
-	SleepDurationProvider SleepDurationProvider
-	Callback              OnRetryCallback
-	Predicates            []RetryPredicate
+	ExpectedRetries              int
+	AttemptTimeout               time.Duration
+	SleepDurationProvider        SleepDurationProvider
+	DelaySleepDurationProvider   DelaySleepDurationProvider
+	OutcomeSleepDurationProvider OutcomeSleepDurationProvider
+	Callback                     OnRetryCallback
+	Predicates                   []RetryPredicate
+	Listeners                    []Listener
 }
 
 // ExecuteVoid calls the given action and applies the policy
 func (it *RetryPolicy) ExecuteVoid(ctx context.Context, action func() error) error {
+	return it.ExecuteVoidContext(ctx, func(context.Context) error { return action() })
+}
+
+// Execute calls the given action and applies the policy
+func (it *RetryPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	return it.ExecuteContext(ctx, func(context.Context) (interface{}, error) { return action() })
+}
+
+// ExecuteVoidContext calls the given action and applies the policy
+// Every attempt is handed a context carrying its Attempt
+func (it *RetryPolicy) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
 	tryCount := 0
+	start := it.clock().Now()
+	errs := []error{}
+	var delay time.Duration
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			err := action()
+			it.notify(Event{Kind: AttemptStarted, Attempt: tryCount + 1})
+			attempt := Attempt{Number: tryCount + 1, Delay: delay}
+			attemptCtx, cancel := it.attemptContext(ctx, attempt)
+			attemptCtx, observed := observeAttempt(attemptCtx, attempt)
+			err := action(attemptCtx)
+			timedOut := it.attemptTimedOut(ctx, attemptCtx, err)
+			cancel()
+			if timedOut {
+				err = TimeoutRejectedError{Timeout: it.AttemptTimeout}
+			}
+			observed(nil, err)
 			if err == nil {
 				return nil
 			}
 
-			if !it.ShouldHandle(err) {
+			it.notify(Event{Kind: AttemptFailed, Attempt: tryCount + 1, Err: err})
+			if !timedOut && !it.handlesError(err) {
 				return err
 			}
 
-			if !it.sleepIfRetryable(tryCount) {
-				return err
+			errs = append(errs, err)
+			retry, sleepDuration, ctxErr := it.sleepIfRetryable(ctx, tryCount, delay, err, nil)
+			if ctxErr != nil {
+				return ctxErr
+			}
+			if !retry {
+				exhausted := it.exhausted(start, tryCount, errs)
+				it.notify(Event{Kind: GaveUp, Attempt: tryCount + 1, Err: exhausted})
+				return exhausted
 			}
 
 			it.Callback(err, tryCount)
+			delay = sleepDuration
 		}
 		tryCount++
 	}
 }
 
-// Execute calls the given action and applies the policy
-func (it *RetryPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+// ExecuteContext calls the given action and applies the policy
+// Every attempt is handed a context carrying its Attempt
+func (it *RetryPolicy) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
 	tryCount := 0
+	start := it.clock().Now()
+	errs := []error{}
+	var delay time.Duration
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
-			val, err := action()
+			it.notify(Event{Kind: AttemptStarted, Attempt: tryCount + 1})
+			attempt := Attempt{Number: tryCount + 1, Delay: delay}
+			attemptCtx, cancel := it.attemptContext(ctx, attempt)
+			attemptCtx, observed := observeAttempt(attemptCtx, attempt)
+			val, err := action(attemptCtx)
+			timedOut := it.attemptTimedOut(ctx, attemptCtx, err)
+			cancel()
+			if timedOut {
+				err = TimeoutRejectedError{Timeout: it.AttemptTimeout}
+			}
+			observed(val, err)
 
 			if err == nil {
 				for _, pred := range it.Predicates {
@@ -62,30 +114,114 @@ func (it *RetryPolicy) Execute(ctx context.Context, action func() (interface{},
 				}
 			}
 
-			if !it.ShouldHandle(err) {
+			handledErr := err
+			handledResult := it.handlesResult(val, err)
+			if handledResult {
+				handledErr = HandledResultError{Result: val}
+			}
+			if handledErr != nil {
+				it.notify(Event{Kind: AttemptFailed, Attempt: tryCount + 1, Err: handledErr, Result: val})
+			}
+			if !handledResult && !timedOut && !it.handlesError(err) {
 				return val, err
 			}
 
-			if !it.sleepIfRetryable(tryCount) {
-				return val, err
+			if handledErr != nil {
+				errs = append(errs, handledErr)
+			}
+			retry, sleepDuration, ctxErr := it.sleepIfRetryable(ctx, tryCount, delay, handledErr, val)
+			if ctxErr != nil {
+				return nil, ctxErr
+			}
+			if !retry {
+				if handledErr == nil {
+					// retried as demanded by the Predicates, so the last result is a regular one
+					it.notify(Event{Kind: GaveUp, Attempt: tryCount + 1})
+					return val, nil
+				}
+				exhausted := it.exhausted(start, tryCount, errs)
+				it.notify(Event{Kind: GaveUp, Attempt: tryCount + 1, Err: exhausted})
+				return val, exhausted
 			}
 
-			it.Callback(err, tryCount)
+			it.Callback(handledErr, tryCount)
+			delay = sleepDuration
 		}
 		tryCount++
 	}
 }
 
-func (it *RetryPolicy) sleepIfRetryable(tryCount int) bool {
-	sleepDuration, durationProvided := it.SleepDurationProvider(tryCount)
+// attemptContext derives the context of a single attempt, bound by the AttemptTimeout if set
+func (it *RetryPolicy) attemptContext(ctx context.Context, attempt Attempt) (context.Context, context.CancelFunc) {
+	ctx = withAttempt(ctx, attempt)
+	if it.AttemptTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return withTimeout(ctx, it.clock(), it.AttemptTimeout)
+}
+
+// attemptTimedOut reports whether an attempt failed because of its own timeout rather than the execution's context
+func (it *RetryPolicy) attemptTimedOut(ctx, attemptCtx context.Context, err error) bool {
+	return err != nil && ctx.Err() == nil && attemptCtx.Err() == context.DeadlineExceeded
+}
+
+// sleepIfRetryable waits before the next try and reports whether to retry at all
+// The wait is cut short with the context's error as soon as the context is done.
+// If the context's deadline falls before the next try would start or the context forbids retries, no retry is made.
+func (it *RetryPolicy) sleepIfRetryable(ctx context.Context, tryCount int, previous time.Duration, err error, val interface{}) (bool, time.Duration, error) {
+	if retriesDisabled(ctx) {
+		return false, 0, nil
+	}
+
+	sleepDuration, durationProvided := it.sleepDuration(tryCount, previous, err, val)
 	canRetry := tryCount < it.ExpectedRetries || durationProvided
 	if !canRetry {
-		return false
+		return false, 0, nil
+	}
+
+	// a context's deadline is wall-clock time, so it is compared to the time package rather than the policy's Clock
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < sleepDuration {
+		return false, 0, nil
+	}
+
+	it.notify(Event{Kind: RetryScheduled, Attempt: tryCount + 2, Delay: sleepDuration, Err: err})
+	timer := it.clock().NewTimer(sleepDuration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false, 0, ctx.Err()
+	case <-timer.C():
+		return true, sleepDuration, nil
 	}
+}
+
+// notify hands the event to all listeners
+func (it *RetryPolicy) notify(event Event) {
+	event.Policy = it
+	event.At = it.clock().Now()
+	notify(it.Listeners, event)
+}
 
-	time.Sleep(sleepDuration)
+func (it *RetryPolicy) exhausted(start time.Time, tryCount int, errs []error) RetryExhaustedError {
+	return RetryExhaustedError{
+		Attempts: tryCount + 1,
+		Elapsed:  it.clock().Now().Sub(start),
+		Errors:   errs,
+	}
+}
 
-	return true
+// sleepDuration asks the OutcomeSleepDurationProvider, the DelaySleepDurationProvider or the SleepDurationProvider
+// for the duration to sleep before the next try, whichever is set first
+func (it *RetryPolicy) sleepDuration(tryCount int, previous time.Duration, err error, val interface{}) (time.Duration, bool) {
+	if it.OutcomeSleepDurationProvider != nil {
+		return it.OutcomeSleepDurationProvider(tryCount, err, val)
+	}
+	if it.DelaySleepDurationProvider != nil {
+		return it.DelaySleepDurationProvider(tryCount, previous)
+	}
+	return it.SleepDurationProvider(tryCount)
 }
 
 // OnRetryCallback is executed on every retry
@@ -96,3 +232,34 @@ type RetryPredicate func(val interface{}) bool
 
 // RetryOption modifies the RetryPolicy
 type RetryOption func(*RetryPolicy)
+
+// RetryExhaustedError signalizes that the policy gave up retrying
+// Errors holds the error of every failed attempt, a HandledResultError for attempts returning a handled result,
+// the last one being the cause of giving up
+type RetryExhaustedError struct {
+	Attempts int
+	Elapsed  time.Duration
+	Errors   []error
+}
+
+func (it RetryExhaustedError) Error() string {
+	return fmt.Sprintf("retries exhausted after %d attempts in %v: %v", it.Attempts, it.Elapsed, it.Last())
+}
+
+// Last returns the error of the last attempt
+func (it RetryExhaustedError) Last() error {
+	if len(it.Errors) == 0 {
+		return nil
+	}
+	return it.Errors[len(it.Errors)-1]
+}
+
+// Unwrap makes errors.Is and errors.As find the errors of all attempts
+// They are returned newest first, so errors.As finds the last matching one.
+func (it RetryExhaustedError) Unwrap() []error {
+	errs := make([]error, len(it.Errors))
+	for i, err := range it.Errors {
+		errs[len(errs)-1-i] = err
+	}
+	return errs
+}
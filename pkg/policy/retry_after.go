@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryAfterHint is implemented by errors telling how long to wait before trying again
+type RetryAfterHint interface {
+	RetryAfter() time.Duration
+}
+
+// RetryAfter honours server hints on how long to wait before trying again
+// The hint is taken from errors implementing RetryAfterHint or from the Retry-After header of an *http.Response
+// with status 429 or 503. Without hint the sleep duration of the given fallback is used.
+// Whether to retry at all is always decided by the fallback.
+func RetryAfter(fallback SleepDurationProvider) OutcomeSleepDurationProvider {
+	return func(try int, err error, val interface{}) (time.Duration, bool) {
+		duration, ok := fallback(try)
+
+		if hint, found := retryAfterHint(err, val); found {
+			return hint, ok
+		}
+
+		return duration, ok
+	}
+}
+
+func retryAfterHint(err error, val interface{}) (time.Duration, bool) {
+	var hint RetryAfterHint
+	if errors.As(err, &hint) {
+		return hint.RetryAfter(), true
+	}
+
+	var handled HandledResultError
+	if errors.As(err, &handled) && val == nil {
+		val = handled.Result
+	}
+
+	resp, ok := val.(*http.Response)
+	if !ok || resp == nil {
+		return 0, false
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	return parseRetryAfter(resp.Header.Get("Retry-After"))
+}
+
+// parseRetryAfter parses the value of a Retry-After header, given either in seconds or as HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if wait := time.Until(date); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
@@ -0,0 +1,105 @@
+package policy_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+type RateLimitedError struct {
+	wait time.Duration
+}
+
+func (RateLimitedError) Error() string {
+	return "rate limited"
+}
+
+func (it RateLimitedError) RetryAfter() time.Duration {
+	return it.wait
+}
+
+var defaultFallbackProvider policy.SleepDurationProvider = func(try int) (time.Duration, bool) { return time.Second, try < 2 }
+
+func (test *PolicySuite) TestRetryAfterHonoursErrorHint() {
+	provider := policy.RetryAfter(defaultFallbackProvider)
+
+	dur, ok := provider(0, fmt.Errorf("wrapped: %w", RateLimitedError{wait: time.Minute}), nil)
+
+	assert.Equal(test.T(), time.Minute, dur, "hint of error not honoured")
+	assert.True(test.T(), ok)
+}
+
+func (test *PolicySuite) TestRetryAfterHonoursHeaderInSeconds() {
+	provider := policy.RetryAfter(defaultFallbackProvider)
+
+	dur, _ := provider(0, nil, retryAfterResponse(http.StatusTooManyRequests, "120"))
+
+	assert.Equal(test.T(), time.Minute*2, dur, "Retry-After header not honoured")
+}
+
+func (test *PolicySuite) TestRetryAfterHonoursHeaderAsDate() {
+	provider := policy.RetryAfter(defaultFallbackProvider)
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+
+	dur, _ := provider(0, nil, retryAfterResponse(http.StatusServiceUnavailable, date))
+
+	assert.True(test.T(), dur > time.Minute*59 && dur <= time.Hour, "Retry-After date not honoured")
+}
+
+func (test *PolicySuite) TestRetryAfterHonoursHandledResult() {
+	provider := policy.RetryAfter(defaultFallbackProvider)
+
+	dur, _ := provider(0, policy.HandledResultError{Result: retryAfterResponse(http.StatusTooManyRequests, "5")}, nil)
+
+	assert.Equal(test.T(), time.Second*5, dur)
+}
+
+func (test *PolicySuite) TestRetryAfterFallsBackWithoutHint() {
+	provider := policy.RetryAfter(defaultFallbackProvider)
+
+	dur, _ := provider(0, fmt.Errorf("fail"), nil)
+	assert.Equal(test.T(), time.Second, dur)
+
+	dur, _ = provider(0, nil, retryAfterResponse(http.StatusInternalServerError, "120"))
+	assert.Equal(test.T(), time.Second, dur, "Retry-After header honoured for status without hint")
+
+	dur, _ = provider(0, nil, retryAfterResponse(http.StatusTooManyRequests, "soon"))
+	assert.Equal(test.T(), time.Second, dur, "invalid Retry-After header honoured")
+
+	_, ok := provider(2, RateLimitedError{wait: time.Minute}, nil)
+	assert.False(test.T(), ok, "hint allowed more retries than the fallback")
+}
+
+func (test *PolicySuite) TestRetryUsesOutcomeSleepDurationProvider() {
+	var sleptFor time.Duration
+	retry := policy.HandleAll().Retry(policy.WithOutcomeSleepDurationProvider(func(try int, err error, val interface{}) (time.Duration, bool) {
+		assert.Equal(test.T(), "val", val)
+		assert.IsType(test.T(), RateLimitedError{}, err)
+		sleptFor = err.(RateLimitedError).wait
+		return sleptFor, false
+	}))
+
+	_, err := retry.Execute(context.Background(), func() (interface{}, error) { return "val", RateLimitedError{wait: time.Nanosecond} })
+
+	assert.NotNil(test.T(), err)
+	assert.Equal(test.T(), time.Nanosecond, sleptFor, "OutcomeSleepDurationProvider not used")
+}
+
+func (test *PolicySuite) TestCircuitBreakerUsesBrokenForOutcomeProvider() {
+	var brokenFor time.Duration
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker(
+		policy.WithBrokenForOutcomeProvider(policy.RetryAfter(defaultFallbackProvider)),
+		policy.WithOnBreakCallback(func(_ error, duration time.Duration) { brokenFor = duration }))
+
+	_ = circuitBreaker.ExecuteVoid(context.Background(), func() error { return RateLimitedError{wait: time.Minute} })
+
+	assert.Equal(test.T(), time.Minute, brokenFor, "BrokenForOutcomeProvider not used")
+}
+
+func retryAfterResponse(status int, retryAfter string) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{"Retry-After": []string{retryAfter}}}
+}
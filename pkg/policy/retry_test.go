@@ -2,11 +2,13 @@ package policy_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/policytest"
 )
 
 func (test *PolicySuite) TestExecuteCalled() {
@@ -171,6 +173,22 @@ func (test *PolicySuite) TestSleepDurationProviderIsUsedOnEachRetry() {
 	assert.Equal(test.T(), expectedCalls, callCount, "was not called like configured in sleepDurationProvider")
 }
 
+func (test *PolicySuite) TestDelaySleepDurationProviderReceivesPreviousDelay() {
+	previousDelays := []time.Duration{}
+	retry := policy.HandleAll().Retry(policy.WithDelaySleepDurationProvider(func(try int, previous time.Duration) (time.Duration, bool) {
+		previousDelays = append(previousDelays, previous)
+		return previous + time.Nanosecond, try < 2
+	}))
+
+	_, err := retry.Execute(context.Background(), func() (interface{}, error) { return nil, fmt.Errorf("fail") })
+	voidErr := retry.ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	assert.NotNil(test.T(), err)
+	assert.NotNil(test.T(), voidErr)
+	expected := []time.Duration{0, time.Nanosecond, time.Nanosecond * 2}
+	assert.Equal(test.T(), append(expected, expected...), previousDelays, "previous delays not handed to provider")
+}
+
 // VOID
 
 func (test *PolicySuite) TestVoidExecuteCalled() {
@@ -308,3 +326,302 @@ func (test *PolicySuite) TestVoidSleepDurationProviderIsUsedOnEachRetry() {
 	assert.NotNil(test.T(), err)
 	assert.Equal(test.T(), expectedCalls, callCount, "was not called like configured in sleepDurationProvider")
 }
+
+// context
+
+func (test *PolicySuite) TestSleepIsInterruptedWhenContextCancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	retry := policy.DefaultRetryPolicy()
+	retry.SleepDurationProvider = func(int) (time.Duration, bool) { return time.Minute, true }
+
+	start := time.Now()
+	_, err := retry.Execute(ctx, func() (interface{}, error) {
+		go func() {
+			time.Sleep(time.Millisecond * 5)
+			cancel()
+		}()
+		return nil, fmt.Errorf("fail")
+	})
+
+	assert.Equal(test.T(), context.Canceled, err)
+	assert.True(test.T(), time.Since(start) < time.Second, "sleep not interrupted by cancelled context")
+}
+
+func (test *PolicySuite) TestSleepIsSkippedWhenDeadlineFallsBeforeNextTry() {
+	callCount := 0
+	expectedErr := fmt.Errorf("fail")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	retry := policy.DefaultRetryPolicy()
+	retry.SleepDurationProvider = func(int) (time.Duration, bool) { return time.Minute, true }
+
+	start := time.Now()
+	_, err := retry.Execute(ctx, func() (interface{}, error) {
+		callCount++
+		return nil, expectedErr
+	})
+
+	assert.True(test.T(), errors.Is(err, expectedErr), "last error not returned")
+	assert.Equal(test.T(), 1, callCount, "retried although deadline falls before next try")
+	assert.True(test.T(), time.Since(start) < time.Second, "slept although deadline falls before next try")
+}
+
+func (test *PolicySuite) TestVoidSleepIsInterruptedWhenContextCancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	retry := policy.DefaultRetryPolicy()
+	retry.SleepDurationProvider = func(int) (time.Duration, bool) { return time.Minute, true }
+
+	start := time.Now()
+	err := retry.ExecuteVoid(ctx, func() error {
+		go func() {
+			time.Sleep(time.Millisecond * 5)
+			cancel()
+		}()
+		return fmt.Errorf("fail")
+	})
+
+	assert.Equal(test.T(), context.Canceled, err)
+	assert.True(test.T(), time.Since(start) < time.Second, "sleep not interrupted by cancelled context")
+}
+
+func (test *PolicySuite) TestRetrySleepsOnClock() {
+	clock := policytest.NewFakeClock(time.Now())
+	retry := policy.DefaultRetryPolicy()
+	retry.Clock = clock
+	retry.SleepDurationProvider = func(int) (time.Duration, bool) { return time.Hour, false }
+
+	done := make(chan error)
+	go func() {
+		done <- retry.ExecuteVoid(context.Background(), defaultFailingVoidAction)
+	}()
+
+	clock.WaitForTimers(1)
+	clock.Advance(time.Hour)
+
+	assert.NotNil(test.T(), <-done)
+}
+
+func (test *PolicySuite) TestSleepIsSkippedOnClockWhenDeadlineFallsBeforeNextTry() {
+	callCount := 0
+	expectedErr := fmt.Errorf("fail")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	retry := policy.DefaultRetryPolicy()
+	retry.Clock = policytest.NewFakeClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	retry.SleepDurationProvider = func(int) (time.Duration, bool) { return time.Hour, true }
+
+	_, err := retry.Execute(ctx, func() (interface{}, error) {
+		callCount++
+		return nil, expectedErr
+	})
+
+	assert.True(test.T(), errors.Is(err, expectedErr), "last error not returned")
+	assert.Equal(test.T(), 1, callCount, "retried although deadline falls before next try")
+}
+
+func (test *PolicySuite) TestRetryWithoutClockUsesSystemClock() {
+	sleep := time.Millisecond * 20
+	withoutClock := policy.DefaultRetryPolicy()
+	withoutClock.BasePolicy = policy.BasePolicy{ShouldHandle: func(error) bool { return true }}
+	withoutClock.SleepDurationProvider = func(int) (time.Duration, bool) { return sleep, false }
+	withNilClock := policy.HandleAll().Retry(policy.WithRetryClock(nil), policy.WithDurations(sleep))
+
+	for _, retry := range []*policy.RetryPolicy{withoutClock, withNilClock} {
+		callCount := 0
+		start := time.Now()
+		err := retry.ExecuteVoid(context.Background(), func() error {
+			callCount++
+			return fmt.Errorf("fail")
+		})
+
+		assert.NotNil(test.T(), err)
+		assert.Equal(test.T(), 2, callCount, "not retried without clock")
+		assert.GreaterOrEqual(test.T(), time.Since(start), sleep, "retry did not sleep on the system clock")
+	}
+}
+
+// results
+
+func (test *PolicySuite) TestRetriesOnHandledResult() {
+	callCount := 0
+	var callbackErr error
+	retry := policy.HandleResult(func(val interface{}) bool { return val == 503 }).
+		Retry(policy.WithRetries(3), policy.WithCallback(func(err error, _ int) { callbackErr = err }))
+
+	val, err := retry.Execute(context.Background(), func() (interface{}, error) {
+		callCount++
+		if callCount < 3 {
+			return 503, nil
+		}
+		return 200, nil
+	})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), 200, val)
+	assert.Equal(test.T(), 3, callCount, "handled result not retried")
+	assert.Equal(test.T(), policy.HandledResultError{Result: 503}, callbackErr, "callback did not receive handled result")
+}
+
+func (test *PolicySuite) TestReturnsHandledResultWhenRetriesExhausted() {
+	retry := policy.HandleResult(func(val interface{}) bool { return val == 503 }).Retry()
+
+	val, err := retry.Execute(context.Background(), func() (interface{}, error) { return 503, nil })
+
+	exhausted, ok := err.(policy.RetryExhaustedError)
+	assert.True(test.T(), ok, "no RetryExhaustedError returned")
+	assert.Equal(test.T(), 2, exhausted.Attempts)
+	assert.Equal(test.T(), policy.HandledResultError{Result: 503}, exhausted.Last(), "handled result not reported")
+	assert.Equal(test.T(), 503, val)
+}
+
+func (test *PolicySuite) TestOuterPoliciesHandleResultsRetriesGaveUpOn() {
+	handles503 := policy.HandleResult(func(val interface{}) bool { return val == 503 })
+	circuitBreaker := handles503.WithCircuitBreaker()
+	fallback := handles503.Fallback(policy.WithFallbackValue(200))
+
+	val, err := policy.Wrap(fallback, circuitBreaker, handles503.Retry()).Execute(context.Background(), func() (interface{}, error) { return 503, nil })
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), 200, val, "fallback not used for result retries gave up on")
+	assert.Equal(test.T(), 1, circuitBreaker.ConsecutiveErrors(), "circuit breaker did not count result retries gave up on")
+}
+
+// exhaustion
+
+func (test *PolicySuite) TestReturnsRetryExhaustedErrorWithAllErrors() {
+	callCount := 0
+	errs := []error{fmt.Errorf("first"), fmt.Errorf("second"), fmt.Errorf("third")}
+	clock := policytest.NewFakeClock(time.Now())
+	retry := policy.HandleAll().Retry(policy.WithRetries(2), policy.WithRetryClock(clock),
+		policy.WithCallback(func(error, int) { clock.Advance(time.Second) }))
+	retry.SleepDurationProvider = func(int) (time.Duration, bool) { return 0, false }
+
+	_, err := retry.Execute(context.Background(), func() (interface{}, error) {
+		callCount++
+		return nil, errs[callCount-1]
+	})
+
+	exhausted, ok := err.(policy.RetryExhaustedError)
+	assert.True(test.T(), ok, "no RetryExhaustedError returned")
+	assert.Equal(test.T(), 3, exhausted.Attempts)
+	assert.Equal(test.T(), time.Second*2, exhausted.Elapsed)
+	assert.Equal(test.T(), errs, exhausted.Errors)
+	assert.Equal(test.T(), errs[2], exhausted.Last())
+	assert.True(test.T(), errors.Is(err, errs[2]), "errors.Is does not find the last error")
+	assert.True(test.T(), errors.Is(err, errs[0]), "errors.Is does not find earlier errors")
+}
+
+func (test *PolicySuite) TestVoidReturnsRetryExhaustedError() {
+	err := policy.DefaultRetryPolicy().ExecuteVoid(context.Background(), func() error { return CustomError{} })
+
+	exhausted, ok := err.(policy.RetryExhaustedError)
+	assert.True(test.T(), ok, "no RetryExhaustedError returned")
+	assert.Equal(test.T(), 2, exhausted.Attempts)
+
+	var customErr CustomError
+	assert.True(test.T(), errors.As(err, &customErr), "errors.As does not find the last error")
+}
+
+type attemptError struct {
+	attempt int
+}
+
+func (it attemptError) Error() string { return fmt.Sprintf("attempt %d failed", it.attempt) }
+
+func (test *PolicySuite) TestErrorsAsFindsErrorOfLastAttempt() {
+	attempts := 0
+	retry := policy.HandleAll().Retry(policy.WithRetries(2), policy.WithDurations(time.Nanosecond))
+
+	err := retry.ExecuteVoid(context.Background(), func() error {
+		attempts++
+		return attemptError{attempt: attempts}
+	})
+
+	var attemptErr attemptError
+	assert.True(test.T(), errors.As(err, &attemptErr))
+	assert.Equal(test.T(), attemptError{attempt: 3}, attemptErr, "errors.As does not find the last error")
+}
+
+func (test *PolicySuite) TestUnhandledErrorIsReturnedUnwrapped() {
+	retry := policy.HandleType(CustomError{}).Retry()
+
+	err := retry.ExecuteVoid(context.Background(), func() error { return AnotherCustomError{} })
+
+	assert.Equal(test.T(), AnotherCustomError{}, err, "unhandled error wrapped")
+}
+
+func (test *PolicySuite) TestRetryExhaustedError() {
+	err := policy.RetryExhaustedError{Attempts: 2, Elapsed: time.Second, Errors: []error{fmt.Errorf("first"), fmt.Errorf("second")}}
+
+	assert.Equal(test.T(), "retries exhausted after 2 attempts in 1s: second", err.Error())
+}
+
+// attempt timeout
+
+func (test *PolicySuite) TestAttemptTimeoutRetriesHungAttempt() {
+	callCount := 0
+	retry := policy.HandleType(CustomError{}).Retry(policy.WithAttemptTimeout(time.Millisecond * 5))
+
+	val, err := retry.ExecuteContext(context.Background(), func(ctx context.Context) (interface{}, error) {
+		callCount++
+		if callCount == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return "test", nil
+	})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "test", val)
+	assert.Equal(test.T(), 2, callCount, "timed out attempt not retried")
+}
+
+func (test *PolicySuite) TestAttemptTimeoutIsReportedWhenRetriesExhausted() {
+	retry := policy.HandleType(CustomError{}).Retry(policy.WithAttemptTimeout(time.Millisecond))
+
+	err := retry.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	exhausted, ok := err.(policy.RetryExhaustedError)
+	assert.True(test.T(), ok, "no RetryExhaustedError returned")
+	assert.Equal(test.T(), 2, exhausted.Attempts)
+	assert.IsType(test.T(), policy.TimeoutRejectedError{}, exhausted.Last())
+}
+
+func (test *PolicySuite) TestAttemptTimeoutElapsesOnClock() {
+	clock := policytest.NewFakeClock(time.Now())
+	retry := policy.HandleAll().Retry(policy.WithRetryClock(clock), policy.WithRetries(0), policy.WithAttemptTimeout(time.Minute))
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- retry.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	clock.WaitForTimers(1)
+	clock.Advance(time.Minute)
+
+	exhausted := policy.RetryExhaustedError{}
+	assert.True(test.T(), errors.As(<-errs, &exhausted), "no RetryExhaustedError returned")
+	assert.IsType(test.T(), policy.TimeoutRejectedError{}, exhausted.Last(), "attempt not timed out by the clock")
+}
+
+func (test *PolicySuite) TestExecutionContextBoundsAttemptTimeouts() {
+	callCount := 0
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*5)
+	defer cancel()
+	retry := policy.HandleAll().Retry(policy.WithRetries(100), policy.WithAttemptTimeout(time.Minute))
+
+	err := retry.ExecuteVoidContext(ctx, func(ctx context.Context) error {
+		callCount++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.True(test.T(), errors.Is(err, context.DeadlineExceeded), "execution's deadline not reported")
+	assert.False(test.T(), errors.As(err, new(policy.TimeoutRejectedError)), "execution's deadline reported as attempt timeout")
+	assert.Equal(test.T(), 1, callCount, "retried after the execution's deadline passed")
+}
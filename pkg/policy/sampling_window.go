@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"time"
+)
+
+// samplingBuckets is the number of buckets a sampling window is split into
+const samplingBuckets = 10
+
+// samplingWindow counts outcomes within a rolling time window
+// The window is split into a fixed number of buckets, so its memory stays bounded no matter the throughput
+type samplingWindow struct {
+	duration       time.Duration
+	bucketDuration time.Duration
+	buckets        [samplingBuckets]samplingBucket
+	// origin is the start of the first bucket ever used, buckets are indexed relative to it
+	origin   time.Time
+	anchored bool
+}
+
+type samplingBucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+func newSamplingWindow(duration time.Duration) *samplingWindow {
+	bucketDuration := duration / samplingBuckets
+	if bucketDuration <= 0 {
+		bucketDuration = 1
+	}
+
+	return &samplingWindow{
+		duration:       duration,
+		bucketDuration: bucketDuration,
+	}
+}
+
+func (it *samplingWindow) record(now time.Time, failed bool) {
+	bucket := it.bucketAt(now)
+	if failed {
+		bucket.failures++
+	} else {
+		bucket.successes++
+	}
+}
+
+// health returns the number of outcomes within the window and the ratio of failures among them
+func (it *samplingWindow) health(now time.Time) (throughput int, failureRate float64) {
+	oldest := now.Add(-it.duration)
+	failures := 0
+
+	for _, bucket := range it.buckets {
+		if !bucket.start.After(oldest) {
+			continue
+		}
+		throughput += bucket.successes + bucket.failures
+		failures += bucket.failures
+	}
+
+	if throughput == 0 {
+		return 0, 0
+	}
+
+	return throughput, float64(failures) / float64(throughput)
+}
+
+func (it *samplingWindow) reset() {
+	it.buckets = [samplingBuckets]samplingBucket{}
+}
+
+// bucketAt returns the bucket the given time falls into
+// Buckets are indexed relative to the window's origin rather than the Unix epoch,
+// so times a Clock reports before 1970 or beyond the range of UnixNano are counted as well.
+func (it *samplingWindow) bucketAt(now time.Time) *samplingBucket {
+	start := now.Truncate(it.bucketDuration)
+	if !it.anchored {
+		it.origin, it.anchored = start, true
+	}
+
+	index := int64(start.Sub(it.origin)/it.bucketDuration) % samplingBuckets
+	if index < 0 {
+		index += samplingBuckets
+	}
+	bucket := &it.buckets[index]
+
+	if !bucket.start.Equal(start) {
+		*bucket = samplingBucket{start: start}
+	}
+
+	return bucket
+}
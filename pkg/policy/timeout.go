@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutStrategy defines how a TimeoutPolicy enforces its timeout
+type TimeoutStrategy int
+
+const (
+	// Optimistic cancels the context handed to the action and relies on the action honouring it
+	Optimistic TimeoutStrategy = iota
+	// Pessimistic stops waiting for the action as soon as the timeout elapsed, even if the action ignores cancellation
+	Pessimistic
+)
+
+// TimeoutPolicy is a policy bounding the time a single execution may take
+type TimeoutPolicy struct {
+	BasePolicy
+
+	Timeout   time.Duration
+	Strategy  TimeoutStrategy
+	Listeners []Listener
+}
+
+// ExecuteVoid calls the given action and applies the policy
+// As the action does not receive a context, only the Pessimistic strategy is able to bound its execution time
+func (it *TimeoutPolicy) ExecuteVoid(ctx context.Context, action func() error) error {
+	return it.ExecuteVoidContext(ctx, func(context.Context) error { return action() })
+}
+
+// Execute calls the given action and applies the policy
+// As the action does not receive a context, only the Pessimistic strategy is able to bound its execution time
+func (it *TimeoutPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	return it.ExecuteContext(ctx, func(context.Context) (interface{}, error) { return action() })
+}
+
+// ExecuteVoidContext calls the given action with a context cancelled after the timeout and applies the policy
+func (it *TimeoutPolicy) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
+	_, err := it.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) { return nil, action(ctx) })
+	return err
+}
+
+// ExecuteContext calls the given action with a context cancelled after the timeout and applies the policy
+func (it *TimeoutPolicy) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	timeoutCtx, cancel := withTimeout(ctx, it.clock(), it.Timeout)
+	defer cancel()
+
+	if it.Strategy == Pessimistic {
+		return it.executePessimistic(ctx, timeoutCtx, action)
+	}
+
+	val, err := action(timeoutCtx)
+	if err != nil && ctx.Err() == nil && timeoutCtx.Err() == context.DeadlineExceeded {
+		return val, it.reject()
+	}
+
+	return val, err
+}
+
+func (it *TimeoutPolicy) executePessimistic(ctx, timeoutCtx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	type outcome struct {
+		val interface{}
+		err error
+	}
+	// buffered, so an abandoned action does not leak its goroutine
+	done := make(chan outcome, 1)
+
+	go func() {
+		val, err := action(timeoutCtx)
+		done <- outcome{val: val, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.val, res.err
+	case <-timeoutCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, it.reject()
+	}
+}
+
+// reject notifies the listeners about an execution not finishing within the timeout and returns its error
+func (it *TimeoutPolicy) reject() error {
+	err := TimeoutRejectedError{Timeout: it.Timeout}
+	notify(it.Listeners, Event{Kind: CallRejected, Policy: it, At: it.clock().Now(), Err: err})
+	return err
+}
+
+// TimeoutOption modifies the TimeoutPolicy
+type TimeoutOption func(*TimeoutPolicy)
+
+// TimeoutRejectedError signalizes that an execution did not finish within the configured timeout
+type TimeoutRejectedError struct {
+	Timeout time.Duration
+}
+
+func (it TimeoutRejectedError) Error() string {
+	return fmt.Sprintf("execution timed out after %v", it.Timeout)
+}
+
+// Unwrap makes errors.Is(err, context.DeadlineExceeded) hold for a TimeoutRejectedError
+func (TimeoutRejectedError) Unwrap() error {
+	return context.DeadlineExceeded
+}
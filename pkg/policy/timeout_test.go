@@ -0,0 +1,134 @@
+package policy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/policytest"
+)
+
+func (test *PolicySuite) TestTimeoutReturnsActionResult() {
+	timeout := policy.DefaultTimeoutPolicy()
+
+	val, err := timeout.Execute(context.Background(), func() (interface{}, error) { return "test", nil })
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "test", val, "action's return value not returned")
+}
+
+func (test *PolicySuite) TestOptimisticTimeoutCancelsContext() {
+	timeout := policy.DefaultTimeoutPolicy()
+	timeout.Timeout = time.Millisecond * 5
+
+	_, err := timeout.ExecuteContext(context.Background(), func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	assert.IsType(test.T(), policy.TimeoutRejectedError{}, err)
+	assert.True(test.T(), errors.Is(err, context.DeadlineExceeded), "TimeoutRejectedError does not unwrap to context.DeadlineExceeded")
+}
+
+func (test *PolicySuite) TestOptimisticTimeoutKeepsSuccessfulResult() {
+	timeout := policy.DefaultTimeoutPolicy()
+	timeout.Timeout = time.Millisecond
+
+	val, err := timeout.ExecuteContext(context.Background(), func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return "test", nil
+	})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "test", val)
+}
+
+func (test *PolicySuite) TestOptimisticTimeoutReturnsParentContextError() {
+	ctx, cancel := context.WithCancel(context.Background())
+	timeout := policy.DefaultTimeoutPolicy()
+
+	err := timeout.ExecuteVoidContext(ctx, func(ctx context.Context) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.Equal(test.T(), context.Canceled, err)
+}
+
+func (test *PolicySuite) TestPessimisticTimeoutAbandonsAction() {
+	release := make(chan struct{})
+	defer close(release)
+	timeout := policy.DefaultTimeoutPolicy()
+	timeout.Timeout = time.Millisecond * 5
+	timeout.Strategy = policy.Pessimistic
+
+	start := time.Now()
+	_, err := timeout.Execute(context.Background(), func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+
+	assert.IsType(test.T(), policy.TimeoutRejectedError{}, err)
+	assert.True(test.T(), time.Since(start) < time.Second, "pessimistic timeout waited for the action")
+}
+
+func (test *PolicySuite) TestPessimisticTimeoutReturnsActionError() {
+	expectedErr := fmt.Errorf("fail")
+	timeout := policy.DefaultTimeoutPolicy()
+	timeout.Strategy = policy.Pessimistic
+
+	err := timeout.ExecuteVoid(context.Background(), func() error { return expectedErr })
+
+	assert.Equal(test.T(), expectedErr, err)
+}
+
+func (test *PolicySuite) TestTimeoutElapsesOnClock() {
+	for _, strategy := range []policy.TimeoutStrategy{policy.Optimistic, policy.Pessimistic} {
+		clock := policytest.NewFakeClock(time.Now())
+		timeout := policy.HandleAll().Timeout(policy.WithTimeout(time.Minute), policy.WithTimeoutStrategy(strategy), policy.WithTimeoutClock(clock))
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- timeout.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		}()
+		clock.WaitForTimers(1)
+		clock.Advance(time.Minute)
+
+		err := <-errs
+		assert.IsType(test.T(), policy.TimeoutRejectedError{}, err, "strategy %v not timed out by the clock", strategy)
+		assert.True(test.T(), errors.Is(err, context.DeadlineExceeded))
+	}
+}
+
+func (test *PolicySuite) TestContextsDerivedInActionTimeOutOnClock() {
+	clock := policytest.NewFakeClock(time.Now())
+	timeout := policy.HandleAll().Timeout(policy.WithTimeout(time.Minute), policy.WithTimeoutClock(clock))
+
+	derivedErrs := make(chan error, 1)
+	go func() {
+		_ = timeout.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+			derived, cancel := context.WithCancel(ctx)
+			defer cancel()
+			<-derived.Done()
+			derivedErrs <- derived.Err()
+			return derived.Err()
+		})
+	}()
+	clock.WaitForTimers(1)
+	clock.Advance(time.Minute)
+
+	assert.Equal(test.T(), context.DeadlineExceeded, <-derivedErrs, "derived context cancelled rather than timed out")
+}
+
+func (test *PolicySuite) TestTimeoutRejectedError() {
+	err := policy.TimeoutRejectedError{Timeout: time.Second}
+
+	assert.Equal(test.T(), "execution timed out after 1s", err.Error())
+}
@@ -0,0 +1,40 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+)
+
+// Execute calls the given action with the given policy applied and returns the action's result without type assertions
+func Execute[T any](ctx context.Context, plcy Policy, action func(ctx context.Context) (T, error)) (T, error) {
+	val, err := plcy.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) { return action(ctx) })
+
+	typed, ok := val.(T)
+	if !ok && val != nil && err == nil {
+		return typed, fmt.Errorf("result of type %T is not assignable to %T", val, typed)
+	}
+
+	return typed, err
+}
+
+// TypedPredicate adapts a predicate on typed values to a RetryPredicate
+// Values of another type never satisfy the predicate
+func TypedPredicate[T any](predicate func(val T) bool) RetryPredicate {
+	return func(val interface{}) bool {
+		typed, ok := val.(T)
+		if !ok && val != nil {
+			return false
+		}
+		return predicate(typed)
+	}
+}
+
+// WithTypedPredicates sets typed predicates checking for retry
+func WithTypedPredicates[T any](predicates ...func(val T) bool) RetryOption {
+	retryPredicates := make([]RetryPredicate, 0, len(predicates))
+	for _, predicate := range predicates {
+		retryPredicates = append(retryPredicates, TypedPredicate(predicate))
+	}
+
+	return WithPredicates(retryPredicates...)
+}
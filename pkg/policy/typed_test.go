@@ -0,0 +1,81 @@
+package policy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+func (test *PolicySuite) TestTypedExecuteReturnsTypedResult() {
+	retry := policy.DefaultRetryPolicy()
+
+	val, err := policy.Execute(context.Background(), retry, func(context.Context) (int, error) { return 42, nil })
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), 42, val)
+}
+
+func (test *PolicySuite) TestTypedExecuteHandsContextToAction() {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "test")
+
+	val, err := policy.Execute(ctx, policy.DefaultRetryPolicy(), func(ctx context.Context) (string, error) {
+		return ctx.Value(key{}).(string), nil
+	})
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "test", val, "context not handed to action")
+
+	val, err = policy.Execute(ctx, policy.DefaultTimeoutPolicy(), func(ctx context.Context) (string, error) {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(test.T(), hasDeadline, "policy's context not handed to action")
+		return ctx.Value(key{}).(string), nil
+	})
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "test", val, "context not handed to action")
+}
+
+func (test *PolicySuite) TestTypedExecuteReturnsError() {
+	expectedErr := fmt.Errorf("fail")
+
+	val, err := policy.Execute(context.Background(), policy.DefaultRetryPolicy(), func(context.Context) (*CustomError, error) {
+		return nil, expectedErr
+	})
+
+	assert.True(test.T(), errors.Is(err, expectedErr), "action's error not returned")
+	assert.Nil(test.T(), val)
+}
+
+func (test *PolicySuite) TestTypedExecuteFailsOnMismatchingResult() {
+	fallback := policy.HandleAll().Fallback(policy.WithFallbackValue("fallback"))
+
+	val, err := policy.Execute(context.Background(), fallback, func(context.Context) (int, error) { return 0, fmt.Errorf("fail") })
+
+	assert.NotNil(test.T(), err, "mismatching result type not reported")
+	assert.Equal(test.T(), 0, val)
+}
+
+func (test *PolicySuite) TestTypedPredicateChecksTypedValues() {
+	predicate := policy.TypedPredicate(func(val int) bool { return val > 1 })
+
+	assert.True(test.T(), predicate(2))
+	assert.False(test.T(), predicate(1))
+	assert.False(test.T(), predicate("2"), "value of another type satisfied predicate")
+}
+
+func (test *PolicySuite) TestWithTypedPredicatesRetriesOnTypedValues() {
+	callCount := 0
+	retry := policy.HandleAll().Retry(policy.WithRetries(3), policy.WithTypedPredicates(func(val int) bool { return val < 2 }))
+	retry.ShouldHandle = func(error) bool { return true }
+
+	val, err := policy.Execute(context.Background(), retry, func(context.Context) (int, error) {
+		callCount++
+		return callCount, nil
+	})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), 2, val)
+	assert.Equal(test.T(), 2, callCount, "typed predicates not applied")
+}
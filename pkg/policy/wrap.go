@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"context"
+)
+
+// Wrap nests the given policies, the first one being the outermost
+// Executions flow through every policy in order before the action is called
+func Wrap(outer Policy, inner ...Policy) Policy {
+	return &PolicyWrap{
+		policies: append([]Policy{outer}, inner...),
+	}
+}
+
+// PolicyWrap is a policy combining several nested policies
+type PolicyWrap struct {
+	policies []Policy
+}
+
+// Policies returns the wrapped policies from outermost to innermost
+func (it *PolicyWrap) Policies() []Policy {
+	return append([]Policy{}, it.policies...)
+}
+
+// Handles reports whether any of the wrapped policies handles the given outcome of an execution
+func (it *PolicyWrap) Handles(val interface{}, err error) bool {
+	for _, plcy := range it.policies {
+		if handler, ok := plcy.(OutcomeHandler); ok && handler.Handles(val, err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decorator is implemented by policies applying another policy, e.g. to instrument its executions
+type Decorator interface {
+	// Unwrap returns the decorated policy
+	Unwrap() Policy
+}
+
+// CircuitBreakers returns the circuit breakers among the given policy and the policies wrapped or decorated by it, outermost first
+func CircuitBreakers(plcy Policy) []*CircuitBreakerPolicy {
+	switch p := plcy.(type) {
+	case *CircuitBreakerPolicy:
+		return []*CircuitBreakerPolicy{p}
+	case *PolicyWrap:
+		breakers := []*CircuitBreakerPolicy{}
+		for _, inner := range p.policies {
+			breakers = append(breakers, CircuitBreakers(inner)...)
+		}
+		return breakers
+	case Decorator:
+		return CircuitBreakers(p.Unwrap())
+	default:
+		return nil
+	}
+}
+
+// ExecuteVoid calls the given action and applies all wrapped policies
+func (it *PolicyWrap) ExecuteVoid(ctx context.Context, action func() error) error {
+	return it.ExecuteVoidContext(ctx, func(context.Context) error { return action() })
+}
+
+// Execute calls the given action and applies all wrapped policies
+func (it *PolicyWrap) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	return it.ExecuteContext(ctx, func(context.Context) (interface{}, error) { return action() })
+}
+
+// ExecuteVoidContext calls the given action and applies all wrapped policies
+// Every policy hands its context down to the next one
+func (it *PolicyWrap) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
+	return executeVoidWrapped(ctx, it.policies, action)
+}
+
+// ExecuteContext calls the given action and applies all wrapped policies
+// Every policy hands its context down to the next one
+func (it *PolicyWrap) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	return executeWrapped(ctx, it.policies, action)
+}
+
+func executeVoidWrapped(ctx context.Context, policies []Policy, action func(ctx context.Context) error) error {
+	if len(policies) == 0 {
+		return action(ctx)
+	}
+
+	return policies[0].ExecuteVoidContext(ctx, func(ctx context.Context) error {
+		return executeVoidWrapped(ctx, policies[1:], action)
+	})
+}
+
+func executeWrapped(ctx context.Context, policies []Policy, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if len(policies) == 0 {
+		return action(ctx)
+	}
+
+	return policies[0].ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		return executeWrapped(ctx, policies[1:], action)
+	})
+}
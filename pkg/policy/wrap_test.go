@@ -0,0 +1,127 @@
+package policy_test
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+func (test *PolicySuite) TestWrapFlowsThroughPoliciesInOrder() {
+	calls := []string{}
+	wrap := policy.Wrap(recordingPolicy{name: "outer", calls: &calls}, recordingPolicy{name: "middle", calls: &calls}, recordingPolicy{name: "inner", calls: &calls})
+
+	val, err := wrap.Execute(context.Background(), func() (interface{}, error) {
+		calls = append(calls, "action")
+		return "test", nil
+	})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), "test", val)
+	assert.Equal(test.T(), []string{"outer", "middle", "inner", "action"}, calls, "policies not applied in order")
+}
+
+func (test *PolicySuite) TestWrapVoidFlowsThroughPoliciesInOrder() {
+	calls := []string{}
+	wrap := policy.Wrap(recordingPolicy{name: "outer", calls: &calls}, recordingPolicy{name: "inner", calls: &calls})
+
+	err := wrap.ExecuteVoid(context.Background(), func() error {
+		calls = append(calls, "action")
+		return nil
+	})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), []string{"outer", "inner", "action"}, calls, "policies not applied in order")
+}
+
+func (test *PolicySuite) TestWrapRetriesAroundCircuitBreaker() {
+	callCount := 0
+	retry := policy.HandleType(CustomError{}).Retry(policy.WithRetries(3))
+	circuitBreaker := policy.HandleType(CustomError{}).WithCircuitBreaker()
+	circuitBreaker.MaxErrors = 2
+
+	_, err := policy.Wrap(retry, circuitBreaker).Execute(context.Background(), func() (interface{}, error) {
+		callCount++
+		return nil, CustomError{}
+	})
+
+	assert.IsType(test.T(), policy.CircuitBrokenError{}, err)
+	assert.Equal(test.T(), 2, callCount, "circuit breaker did not stop the retries")
+}
+
+func (test *PolicySuite) TestWrapCanBeNested() {
+	calls := []string{}
+	inner := policy.Wrap(recordingPolicy{name: "middle", calls: &calls}, recordingPolicy{name: "inner", calls: &calls})
+	wrap := policy.Wrap(recordingPolicy{name: "outer", calls: &calls}, inner)
+
+	err := wrap.ExecuteVoid(context.Background(), func() error { return nil })
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), []string{"outer", "middle", "inner"}, calls)
+}
+
+func (test *PolicySuite) TestWrapReturnsPolicies() {
+	outer := policy.DefaultRetryPolicy()
+	inner := policy.DefaultCircuitBreakerPolicy()
+
+	wrap := policy.Wrap(outer, inner).(*policy.PolicyWrap)
+
+	assert.Equal(test.T(), []policy.Policy{outer, inner}, wrap.Policies())
+}
+
+type recordingPolicy struct {
+	name  string
+	calls *[]string
+}
+
+func (it recordingPolicy) ExecuteVoid(ctx context.Context, action func() error) error {
+	*it.calls = append(*it.calls, it.name)
+	return action()
+}
+
+func (it recordingPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	*it.calls = append(*it.calls, it.name)
+	return action()
+}
+
+func (it recordingPolicy) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
+	*it.calls = append(*it.calls, it.name)
+	return action(ctx)
+}
+
+func (it recordingPolicy) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	*it.calls = append(*it.calls, it.name)
+	return action(ctx)
+}
+
+func (test *PolicySuite) TestWrapHandsContextOfOuterPolicyDown() {
+	wrap := policy.Wrap(policy.DefaultTimeoutPolicy(), policy.DefaultCircuitBreakerPolicy(), policy.DefaultFallbackPolicy())
+
+	err := wrap.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(test.T(), hasDeadline, "context of timeout policy not handed down")
+		return nil
+	})
+
+	assert.Nil(test.T(), err)
+}
+
+func (test *PolicySuite) TestWrapHandlesOutcomesHandledByAnyPolicy() {
+	wrap := policy.Wrap(policy.HandleType(CustomError{}).Retry(), policy.HandleResult(func(val interface{}) bool { return val == 503 }).Retry())
+
+	handler, ok := wrap.(policy.OutcomeHandler)
+	assert.True(test.T(), ok, "wrap does not tell its handled outcomes")
+	assert.True(test.T(), handler.Handles(nil, CustomError{}), "error handled by outer policy not reported")
+	assert.True(test.T(), handler.Handles(503, nil), "result handled by inner policy not reported")
+	assert.False(test.T(), handler.Handles(nil, AnotherCustomError{}), "unhandled error reported")
+}
+
+func (test *PolicySuite) TestCircuitBreakersFindsNestedCircuitBreakers() {
+	outer := policy.DefaultCircuitBreakerPolicy()
+	inner := policy.DefaultCircuitBreakerPolicy()
+	wrap := policy.Wrap(outer, policy.DefaultRetryPolicy(), policy.Wrap(policy.DefaultTimeoutPolicy(), inner))
+
+	assert.Equal(test.T(), []*policy.CircuitBreakerPolicy{outer, inner}, policy.CircuitBreakers(wrap))
+	assert.Equal(test.T(), []*policy.CircuitBreakerPolicy{outer}, policy.CircuitBreakers(outer))
+	assert.Empty(test.T(), policy.CircuitBreakers(policy.DefaultRetryPolicy()))
+}
@@ -0,0 +1,123 @@
+// Package policyconfig builds policies from YAML or JSON config documents
+//
+// A document maps policy names to pipelines of steps, the first step being the outermost policy:
+//
+//	policies:
+//	  payments:
+//	    - retry:
+//	        retries: 3
+//	        backoff: exponential
+//	        delay: 100ms
+//	        maxDelay: 2s
+//	        handle: [transient]
+//	    - circuitBreaker:
+//	        maxErrors: 5
+//	        breakDuration: 30s
+//
+// Handled errors and results refer to predicates registered by name with the Loader.
+package policyconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a config document
+type Config struct {
+	Policies map[string][]Step `yaml:"policies" json:"policies"`
+}
+
+// Step is a single policy of a pipeline, exactly one of its fields must be set
+type Step struct {
+	Retry          *RetryConfig          `yaml:"retry" json:"retry"`
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuitBreaker" json:"circuitBreaker"`
+	Timeout        *TimeoutConfig        `yaml:"timeout" json:"timeout"`
+	Bulkhead       *BulkheadConfig       `yaml:"bulkhead" json:"bulkhead"`
+}
+
+// Handling names the predicates of the errors and results a policy handles
+// Without any predicate all errors are handled.
+type Handling struct {
+	Handle       []string `yaml:"handle" json:"handle"`
+	HandleResult []string `yaml:"handleResult" json:"handleResult"`
+}
+
+// RetryConfig configures a RetryPolicy
+// The sleep durations are either given as Durations or by a Backoff of the package backoff:
+// constant (Delay), linear (Delay, Step), exponential, fibonacci, fullJitter, equalJitter or decorrelatedJitter (Delay, MaxDelay).
+// Retries defaults to the number of Durations, but at least DefaultRetries.
+// Delays not used by the Backoff are rejected.
+type RetryConfig struct {
+	Handling       `yaml:",inline"`
+	Retries        *int       `yaml:"retries" json:"retries"`
+	Durations      []Duration `yaml:"durations" json:"durations"`
+	Backoff        string     `yaml:"backoff" json:"backoff"`
+	Delay          Duration   `yaml:"delay" json:"delay"`
+	Step           Duration   `yaml:"step" json:"step"`
+	MaxDelay       Duration   `yaml:"maxDelay" json:"maxDelay"`
+	AttemptTimeout Duration   `yaml:"attemptTimeout" json:"attemptTimeout"`
+}
+
+// CircuitBreakerConfig configures a CircuitBreakerPolicy
+type CircuitBreakerConfig struct {
+	Handling       `yaml:",inline"`
+	MaxErrors      int                `yaml:"maxErrors" json:"maxErrors"`
+	BreakDuration  Duration           `yaml:"breakDuration" json:"breakDuration"`
+	HalfOpenTrials int                `yaml:"halfOpenTrials" json:"halfOpenTrials"`
+	FailureRate    *FailureRateConfig `yaml:"failureRate" json:"failureRate"`
+}
+
+// FailureRateConfig makes a circuit break based on the failure rate instead of consecutive errors
+type FailureRateConfig struct {
+	Threshold         float64  `yaml:"threshold" json:"threshold"`
+	SamplingDuration  Duration `yaml:"samplingDuration" json:"samplingDuration"`
+	MinimumThroughput int      `yaml:"minimumThroughput" json:"minimumThroughput"`
+}
+
+// TimeoutConfig configures a TimeoutPolicy, Strategy is either optimistic (default) or pessimistic
+// A TimeoutPolicy handles no errors, so Handling is rejected.
+type TimeoutConfig struct {
+	Handling `yaml:",inline"`
+	Timeout  Duration `yaml:"timeout" json:"timeout"`
+	Strategy string   `yaml:"strategy" json:"strategy"`
+}
+
+// BulkheadConfig configures a BulkheadPolicy
+// A BulkheadPolicy handles no errors, so Handling is rejected.
+type BulkheadConfig struct {
+	Handling       `yaml:",inline"`
+	MaxParallelism int      `yaml:"maxParallelism" json:"maxParallelism"`
+	MaxQueue       int      `yaml:"maxQueue" json:"maxQueue"`
+	QueueTimeout   Duration `yaml:"queueTimeout" json:"queueTimeout"`
+}
+
+// Duration is a duration in the format of time.ParseDuration, e.g. "1m30s"
+type Duration string
+
+// Parse reads a config document, which is JSON if it starts with '{' and YAML otherwise
+// Unknown fields are rejected.
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("parsing policy config: %w", err)
+		}
+		return cfg, nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing policy config: %w", err)
+	}
+
+	return cfg, nil
+}
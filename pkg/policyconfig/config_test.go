@@ -0,0 +1,57 @@
+package policyconfig_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policyconfig"
+)
+
+func (test *PolicyConfigSuite) TestParseReadsYAML() {
+	cfg, err := policyconfig.Parse([]byte(`
+policies:
+  payments:
+    - retry:
+        retries: 3
+        durations: [10ms, 20ms]
+        handle: [transient]
+    - circuitBreaker:
+        maxErrors: 5
+        breakDuration: 30s
+`))
+
+	retries := 3
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), &policyconfig.Config{Policies: map[string][]policyconfig.Step{
+		"payments": {
+			{Retry: &policyconfig.RetryConfig{
+				Handling:  policyconfig.Handling{Handle: []string{"transient"}},
+				Retries:   &retries,
+				Durations: []policyconfig.Duration{"10ms", "20ms"},
+			}},
+			{CircuitBreaker: &policyconfig.CircuitBreakerConfig{MaxErrors: 5, BreakDuration: "30s"}},
+		},
+	}}, cfg)
+}
+
+func (test *PolicyConfigSuite) TestParseReadsJSON() {
+	cfg, err := policyconfig.Parse([]byte(`{"policies": {"search": [{"timeout": {"timeout": "2s", "strategy": "pessimistic"}}]}}`))
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), &policyconfig.Config{Policies: map[string][]policyconfig.Step{
+		"search": {{Timeout: &policyconfig.TimeoutConfig{Timeout: "2s", Strategy: "pessimistic"}}},
+	}}, cfg)
+}
+
+func (test *PolicyConfigSuite) TestParseRejectsUnknownFields() {
+	_, yamlErr := policyconfig.Parse([]byte("policies:\n  payments:\n    - retry:\n        retires: 3\n"))
+	_, jsonErr := policyconfig.Parse([]byte(`{"policies": {"payments": [{"retry": {"retires": 3}}]}}`))
+
+	assert.ErrorContains(test.T(), yamlErr, "retires")
+	assert.ErrorContains(test.T(), jsonErr, "retires")
+}
+
+func (test *PolicyConfigSuite) TestParseAcceptsEmptyDocument() {
+	cfg, err := policyconfig.Parse([]byte("  \n"))
+
+	assert.Nil(test.T(), err)
+	assert.Empty(test.T(), cfg.Policies)
+}
@@ -0,0 +1,501 @@
+package policyconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/typusomega/poligo/pkg/backoff"
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// Loader builds policies from config documents
+// Predicates must be registered before loading documents referring to them.
+type Loader struct {
+	predicates       map[string]policy.HandlePredicate
+	resultPredicates map[string]policy.ResultPredicate
+}
+
+// NewLoader creates a Loader without any predicates
+func NewLoader() *Loader {
+	return &Loader{
+		predicates:       map[string]policy.HandlePredicate{},
+		resultPredicates: map[string]policy.ResultPredicate{},
+	}
+}
+
+// RegisterPredicate makes the predicate available to the handle lists of config documents under the given name
+func (it *Loader) RegisterPredicate(name string, predicate policy.HandlePredicate) {
+	it.predicates[name] = predicate
+}
+
+// RegisterResultPredicate makes the predicate available to the handleResult lists of config documents under the given name
+func (it *Loader) RegisterResultPredicate(name string, predicate policy.ResultPredicate) {
+	it.resultPredicates[name] = predicate
+}
+
+// Load parses the config document and builds its policies by name
+func (it *Loader) Load(data []byte) (map[string]policy.Policy, error) {
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return it.Build(cfg)
+}
+
+// LoadInto parses the config document and registers its policies with the registry
+// No policy is registered if the document is invalid or any of its names is taken already.
+func (it *Loader) LoadInto(registry *policy.Registry, data []byte) error {
+	policies, err := it.Load(data)
+	if err != nil {
+		return err
+	}
+
+	names := sortedNames(policies)
+	for _, name := range names {
+		if _, taken := registry.Get(name); taken {
+			return policy.PolicyAlreadyRegisteredError{Name: name}
+		}
+	}
+
+	for i, name := range names {
+		if err := registry.Register(name, policies[name]); err != nil {
+			// the name was taken concurrently, so the policies registered so far are removed again
+			for _, registered := range names[:i] {
+				registry.Remove(registered)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Build builds the policies of the config by name
+// Pipelines of several steps are wrapped, the first step being the outermost policy.
+// All invalid values are reported at once by a ValidationError.
+func (it *Loader) Build(cfg *Config) (map[string]policy.Policy, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("policyconfig: no config given")
+	}
+
+	b := &build{loader: it}
+	policies := map[string]policy.Policy{}
+
+	for _, name := range sortedNames(cfg.Policies) {
+		if plcy := b.pipeline(fmt.Sprintf("policies.%s", name), cfg.Policies[name]); plcy != nil {
+			policies[name] = plcy
+		}
+	}
+
+	if len(b.errs) > 0 {
+		return nil, ValidationError{Errors: b.errs}
+	}
+
+	return policies, nil
+}
+
+// build collects the validation errors while building the policies of a config
+type build struct {
+	loader *Loader
+	errs   []FieldError
+}
+
+func (it *build) fail(path, format string, args ...interface{}) {
+	it.errs = append(it.errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (it *build) pipeline(path string, steps []Step) policy.Policy {
+	if len(steps) == 0 {
+		it.fail(path, "must contain at least one step")
+		return nil
+	}
+
+	policies := []policy.Policy{}
+	for i, step := range steps {
+		if plcy := it.step(fmt.Sprintf("%s[%d]", path, i), step); plcy != nil {
+			policies = append(policies, plcy)
+		}
+	}
+
+	if len(policies) != len(steps) {
+		return nil
+	}
+	if len(policies) == 1 {
+		return policies[0]
+	}
+	return policy.Wrap(policies[0], policies[1:]...)
+}
+
+func (it *build) step(path string, step Step) policy.Policy {
+	kinds := []string{}
+	var plcy policy.Policy
+
+	if step.Retry != nil {
+		kinds = append(kinds, "retry")
+		plcy = it.retry(path+".retry", step.Retry)
+	}
+	if step.CircuitBreaker != nil {
+		kinds = append(kinds, "circuitBreaker")
+		plcy = it.circuitBreaker(path+".circuitBreaker", step.CircuitBreaker)
+	}
+	if step.Timeout != nil {
+		kinds = append(kinds, "timeout")
+		plcy = it.timeout(path+".timeout", step.Timeout)
+	}
+	if step.Bulkhead != nil {
+		kinds = append(kinds, "bulkhead")
+		plcy = it.bulkhead(path+".bulkhead", step.Bulkhead)
+	}
+
+	switch len(kinds) {
+	case 0:
+		it.fail(path, "must be one of retry, circuitBreaker, timeout or bulkhead")
+		return nil
+	case 1:
+		return plcy
+	default:
+		it.fail(path, "must be exactly one of retry, circuitBreaker, timeout or bulkhead, got %s", strings.Join(kinds, ", "))
+		return nil
+	}
+}
+
+func (it *build) retry(path string, cfg *RetryConfig) policy.Policy {
+	builder, ok := it.handling(path, cfg.Handling)
+
+	retries := policy.DefaultRetries
+	if len(cfg.Durations) > retries {
+		retries = len(cfg.Durations)
+	}
+	if cfg.Retries != nil {
+		retries = *cfg.Retries
+		if retries < 0 {
+			it.fail(path+".retries", "must not be negative, got %d", retries)
+			ok = false
+		} else if retries < len(cfg.Durations) {
+			it.fail(path+".retries", "must not be less than the %d durations, got %d", len(cfg.Durations), retries)
+			ok = false
+		}
+	}
+
+	attemptTimeout, durationOK := it.duration(path+".attemptTimeout", cfg.AttemptTimeout)
+	sleepOpt, sleepOK := it.sleepDurations(path, cfg, retries)
+	if !ok || !durationOK || !sleepOK {
+		return nil
+	}
+
+	opts := []policy.RetryOption{policy.WithRetries(retries), policy.WithAttemptTimeout(attemptTimeout)}
+	if sleepOpt != nil {
+		opts = append(opts, sleepOpt)
+	}
+	return builder.Retry(opts...)
+}
+
+// sleepDurations returns the option setting the configured sleep durations, nil if none are configured
+// Durations set but not used by the configured backoff are reported.
+func (it *build) sleepDurations(path string, cfg *RetryConfig, retries int) (policy.RetryOption, bool) {
+	delay := namedDuration{name: "delay", value: cfg.Delay}
+	step := namedDuration{name: "step", value: cfg.Step}
+	maxDelay := namedDuration{name: "maxDelay", value: cfg.MaxDelay}
+
+	if len(cfg.Durations) > 0 {
+		ok := it.unused(path, "must not be given together with durations", delay, step, maxDelay)
+		if cfg.Backoff != "" {
+			it.fail(path+".durations", "must not be given together with a backoff")
+			ok = false
+		}
+
+		durations := make([]time.Duration, len(cfg.Durations))
+		for i, d := range cfg.Durations {
+			var durationOK bool
+			durations[i], durationOK = it.duration(fmt.Sprintf("%s.durations[%d]", path, i), d)
+			ok = ok && durationOK
+		}
+		if !ok {
+			return nil, false
+		}
+
+		return policy.WithDurations(durations...), true
+	}
+
+	ok := true
+	capped := false
+	switch cfg.Backoff {
+	case "":
+		return nil, it.unused(path, "must not be given without a backoff", delay, step, maxDelay)
+	case "constant":
+		ok = it.unused(path, "is not used by the constant backoff", step, maxDelay)
+	case "linear":
+		ok = it.unused(path, "is not used by the linear backoff", maxDelay)
+	case "exponential", "fibonacci", "fullJitter", "equalJitter", "decorrelatedJitter":
+		ok = it.unused(path, fmt.Sprintf("is not used by the %s backoff", cfg.Backoff), step)
+		capped = true
+	default:
+		it.fail(path+".backoff", "must be one of constant, linear, exponential, fibonacci, fullJitter, equalJitter or decorrelatedJitter, got %q", cfg.Backoff)
+		return nil, false
+	}
+
+	delayValue, delayOK := it.duration(path+".delay", cfg.Delay)
+	stepValue, stepOK := it.duration(path+".step", cfg.Step)
+	maxDelayValue, maxDelayOK := it.duration(path+".maxDelay", cfg.MaxDelay)
+	if !ok || !delayOK || !stepOK || !maxDelayOK {
+		return nil, false
+	}
+	if capped && maxDelayValue < delayValue {
+		it.fail(path+".maxDelay", "must not be shorter than the delay")
+		return nil, false
+	}
+
+	var provider policy.SleepDurationProvider
+	switch cfg.Backoff {
+	case "constant":
+		provider = backoff.Constant(delayValue, retries)
+	case "linear":
+		provider = backoff.Linear(delayValue, stepValue, retries)
+	case "exponential":
+		provider = backoff.Exponential(delayValue, maxDelayValue, retries)
+	case "fibonacci":
+		provider = backoff.Fibonacci(delayValue, maxDelayValue, retries)
+	case "fullJitter":
+		provider = backoff.FullJitter(delayValue, maxDelayValue, retries)
+	case "equalJitter":
+		provider = backoff.EqualJitter(delayValue, maxDelayValue, retries)
+	case "decorrelatedJitter":
+		return policy.WithDelaySleepDurationProvider(backoff.DecorrelatedJitter(delayValue, maxDelayValue, retries)), true
+	}
+
+	return policy.WithSleepDurationProvider(provider), true
+}
+
+// namedDuration is a duration of a config document along with its field name
+type namedDuration struct {
+	name  string
+	value Duration
+}
+
+// unused reports the given durations set although they are not used
+func (it *build) unused(path, message string, durations ...namedDuration) bool {
+	ok := true
+	for _, d := range durations {
+		if d.value != "" {
+			it.fail(path+"."+d.name, message)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (it *build) circuitBreaker(path string, cfg *CircuitBreakerConfig) policy.Policy {
+	builder, ok := it.handling(path, cfg.Handling)
+	opts := []policy.CircuitBreakerOption{}
+
+	ok = it.notNegative(path+".maxErrors", cfg.MaxErrors) && ok
+	ok = it.notNegative(path+".halfOpenTrials", cfg.HalfOpenTrials) && ok
+	if cfg.HalfOpenTrials > 0 {
+		opts = append(opts, policy.WithHalfOpenTrials(cfg.HalfOpenTrials))
+	}
+
+	breakDuration, durationOK := it.duration(path+".breakDuration", cfg.BreakDuration)
+	ok = durationOK && ok
+	if breakDuration > 0 {
+		opts = append(opts, policy.WithBrokenForProvider(func(int) (time.Duration, bool) { return breakDuration, true }))
+	}
+
+	if rate := cfg.FailureRate; rate != nil {
+		if rate.Threshold <= 0 || rate.Threshold > 1 {
+			it.fail(path+".failureRate.threshold", "must be greater than 0 and at most 1, got %v", rate.Threshold)
+			ok = false
+		}
+		samplingDuration, durationOK := it.duration(path+".failureRate.samplingDuration", rate.SamplingDuration)
+		if durationOK && samplingDuration <= 0 {
+			it.fail(path+".failureRate.samplingDuration", "must be positive")
+			durationOK = false
+		}
+		if rate.MinimumThroughput < 1 {
+			it.fail(path+".failureRate.minimumThroughput", "must be at least 1, got %d", rate.MinimumThroughput)
+			ok = false
+		}
+		ok = durationOK && ok
+		if ok {
+			opts = append(opts, policy.WithFailureRate(rate.Threshold, samplingDuration, rate.MinimumThroughput))
+		}
+	}
+
+	if !ok {
+		return nil
+	}
+
+	plcy := builder.WithCircuitBreaker(opts...)
+	if cfg.MaxErrors > 0 {
+		plcy.MaxErrors = cfg.MaxErrors
+	}
+	return plcy
+}
+
+func (it *build) timeout(path string, cfg *TimeoutConfig) policy.Policy {
+	ok := it.noHandling(path, "timeout", cfg.Handling)
+	opts := []policy.TimeoutOption{}
+
+	timeout, durationOK := it.duration(path+".timeout", cfg.Timeout)
+	ok = durationOK && ok
+	if timeout > 0 {
+		opts = append(opts, policy.WithTimeout(timeout))
+	}
+
+	switch cfg.Strategy {
+	case "", "optimistic":
+		opts = append(opts, policy.WithTimeoutStrategy(policy.Optimistic))
+	case "pessimistic":
+		opts = append(opts, policy.WithTimeoutStrategy(policy.Pessimistic))
+	default:
+		it.fail(path+".strategy", "must be one of optimistic or pessimistic, got %q", cfg.Strategy)
+		ok = false
+	}
+
+	if !ok {
+		return nil
+	}
+	return policy.HandleAll().Timeout(opts...)
+}
+
+func (it *build) bulkhead(path string, cfg *BulkheadConfig) policy.Policy {
+	ok := it.noHandling(path, "bulkhead", cfg.Handling)
+	opts := []policy.BulkheadOption{policy.WithMaxQueue(cfg.MaxQueue)}
+
+	ok = it.notNegative(path+".maxParallelism", cfg.MaxParallelism) && ok
+	if cfg.MaxParallelism > 0 {
+		opts = append(opts, policy.WithMaxParallelism(cfg.MaxParallelism))
+	}
+	ok = it.notNegative(path+".maxQueue", cfg.MaxQueue) && ok
+
+	queueTimeout, durationOK := it.duration(path+".queueTimeout", cfg.QueueTimeout)
+	ok = durationOK && ok
+	opts = append(opts, policy.WithQueueTimeout(queueTimeout))
+
+	if !ok {
+		return nil
+	}
+	return policy.HandleAll().Bulkhead(opts...)
+}
+
+// noHandling reports the predicates named for a policy of the given kind, which handles no errors or results
+func (it *build) noHandling(path, kind string, cfg Handling) bool {
+	ok := true
+	if len(cfg.Handle) > 0 {
+		it.fail(path+".handle", "must not be given for a %s", kind)
+		ok = false
+	}
+	if len(cfg.HandleResult) > 0 {
+		it.fail(path+".handleResult", "must not be given for a %s", kind)
+		ok = false
+	}
+	return ok
+}
+
+// handling returns a builder handling the errors and results of the named predicates
+func (it *build) handling(path string, cfg Handling) (policy.Builder, bool) {
+	if len(cfg.Handle) == 0 && len(cfg.HandleResult) == 0 {
+		return policy.HandleAll(), true
+	}
+
+	ok := true
+	predicates := []policy.HandlePredicate{}
+	for i, name := range cfg.Handle {
+		predicate, found := it.loader.predicates[name]
+		if !found {
+			it.fail(fmt.Sprintf("%s.handle[%d]", path, i), "unknown predicate %q", name)
+			ok = false
+		}
+		predicates = append(predicates, predicate)
+	}
+
+	resultPredicates := []policy.ResultPredicate{}
+	for i, name := range cfg.HandleResult {
+		predicate, found := it.loader.resultPredicates[name]
+		if !found {
+			it.fail(fmt.Sprintf("%s.handleResult[%d]", path, i), "unknown result predicate %q", name)
+			ok = false
+		}
+		resultPredicates = append(resultPredicates, predicate)
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	builder := policy.Handle(func(err error) bool {
+		for _, predicate := range predicates {
+			if predicate(err) {
+				return true
+			}
+		}
+		return false
+	})
+	for _, predicate := range resultPredicates {
+		builder = builder.OrResult(predicate)
+	}
+
+	return builder, true
+}
+
+func (it *build) duration(path string, d Duration) (time.Duration, bool) {
+	if d == "" {
+		return 0, true
+	}
+
+	parsed, err := time.ParseDuration(string(d))
+	if err != nil {
+		it.fail(path, "invalid duration %q", string(d))
+		return 0, false
+	}
+	if parsed < 0 {
+		it.fail(path, "must not be negative, got %v", parsed)
+		return 0, false
+	}
+
+	return parsed, true
+}
+
+func (it *build) notNegative(path string, value int) bool {
+	if value < 0 {
+		it.fail(path, "must not be negative, got %d", value)
+		return false
+	}
+	return true
+}
+
+func sortedNames[T any](byName map[string]T) []string {
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// FieldError describes an invalid value of a config document
+type FieldError struct {
+	// Path locates the value within the document, e.g. policies.payments[0].retry.retries
+	Path    string
+	Message string
+}
+
+func (it FieldError) Error() string {
+	return it.Path + ": " + it.Message
+}
+
+// ValidationError lists all invalid values of a config document
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (it ValidationError) Error() string {
+	messages := make([]string, len(it.Errors))
+	for i, err := range it.Errors {
+		messages[i] = err.Error()
+	}
+	return "invalid policy config: " + strings.Join(messages, "; ")
+}
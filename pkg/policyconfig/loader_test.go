@@ -0,0 +1,277 @@
+package policyconfig_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/policyconfig"
+)
+
+var errTransient = errors.New("transient")
+
+func newLoader() *policyconfig.Loader {
+	loader := policyconfig.NewLoader()
+	loader.RegisterPredicate("transient", func(err error) bool { return errors.Is(err, errTransient) })
+	loader.RegisterResultPredicate("empty", func(val interface{}) bool { return val == "" })
+	return loader
+}
+
+func (test *PolicyConfigSuite) TestLoadBuildsPipelines() {
+	policies, err := newLoader().Load([]byte(`
+policies:
+  payments:
+    - retry:
+        retries: 2
+        backoff: exponential
+        delay: 1ms
+        maxDelay: 4ms
+    - circuitBreaker:
+        maxErrors: 5
+        breakDuration: 30s
+        halfOpenTrials: 2
+  search:
+    - timeout:
+        timeout: 2s
+        strategy: pessimistic
+  reports:
+    - bulkhead:
+        maxParallelism: 4
+        maxQueue: 8
+        queueTimeout: 1s
+`))
+
+	assert.Nil(test.T(), err)
+	assert.Len(test.T(), policies, 3)
+
+	wrap, ok := policies["payments"].(*policy.PolicyWrap)
+	assert.True(test.T(), ok, "pipeline not wrapped")
+	inner := wrap.Policies()
+	assert.Len(test.T(), inner, 2)
+	retry := inner[0].(*policy.RetryPolicy)
+	assert.Equal(test.T(), 2, retry.ExpectedRetries)
+	sleep, _ := retry.SleepDurationProvider(1)
+	assert.Equal(test.T(), 2*time.Millisecond, sleep)
+	breaker := inner[1].(*policy.CircuitBreakerPolicy)
+	assert.Equal(test.T(), 5, breaker.MaxErrors)
+	assert.Equal(test.T(), 2, breaker.HalfOpenTrials)
+	brokenFor, _ := breaker.BrokenForProvider(0)
+	assert.Equal(test.T(), 30*time.Second, brokenFor)
+
+	timeout := policies["search"].(*policy.TimeoutPolicy)
+	assert.Equal(test.T(), 2*time.Second, timeout.Timeout)
+	assert.Equal(test.T(), policy.Pessimistic, timeout.Strategy)
+
+	bulkhead := policies["reports"].(*policy.BulkheadPolicy)
+	assert.Equal(test.T(), 4, bulkhead.MaxParallelism)
+	assert.Equal(test.T(), 8, bulkhead.MaxQueue)
+	assert.Equal(test.T(), time.Second, bulkhead.QueueTimeout)
+}
+
+func (test *PolicyConfigSuite) TestLoadRetriesOncePerDuration() {
+	policies, err := newLoader().Load([]byte(`{"policies": {"payments": [{"retry": {"durations": ["1ms", "1ms", "1ms"]}}]}}`))
+	assert.Nil(test.T(), err)
+
+	attempts := 0
+	_ = policies["payments"].ExecuteVoid(context.Background(), func() error {
+		attempts++
+		return errTransient
+	})
+
+	assert.Equal(test.T(), 4, attempts)
+}
+
+func (test *PolicyConfigSuite) TestLoadHandlesNamedPredicates() {
+	policies, err := newLoader().Load([]byte(`
+policies:
+  payments:
+    - retry:
+        retries: 1
+        handle: [transient]
+        handleResult: [empty]
+`))
+	assert.Nil(test.T(), err)
+	plcy := policies["payments"]
+
+	transientAttempts := 0
+	_ = plcy.ExecuteVoid(context.Background(), func() error {
+		transientAttempts++
+		return errTransient
+	})
+	otherAttempts := 0
+	_ = plcy.ExecuteVoid(context.Background(), func() error {
+		otherAttempts++
+		return errors.New("permanent")
+	})
+	resultAttempts := 0
+	_, _ = plcy.Execute(context.Background(), func() (interface{}, error) {
+		resultAttempts++
+		return "", nil
+	})
+
+	assert.Equal(test.T(), 2, transientAttempts, "handled error not retried")
+	assert.Equal(test.T(), 1, otherAttempts, "unhandled error retried")
+	assert.Equal(test.T(), 2, resultAttempts, "handled result not retried")
+}
+
+func (test *PolicyConfigSuite) TestLoadReportsAllInvalidValues() {
+	_, err := newLoader().Load([]byte(`
+policies:
+  payments:
+    - retry:
+        retries: -1
+        backoff: quadratic
+        handle: [transient, flaky]
+    - circuitBreaker:
+        breakDuration: soon
+        failureRate:
+          threshold: 1.5
+  search:
+    - timeout:
+        strategy: eager
+      bulkhead:
+        maxQueue: 1
+  empty: []
+`))
+
+	validationErr := policyconfig.ValidationError{}
+	assert.True(test.T(), errors.As(err, &validationErr))
+	assert.Equal(test.T(), []policyconfig.FieldError{
+		{Path: "policies.empty", Message: "must contain at least one step"},
+		{Path: "policies.payments[0].retry.handle[1]", Message: `unknown predicate "flaky"`},
+		{Path: "policies.payments[0].retry.retries", Message: "must not be negative, got -1"},
+		{Path: "policies.payments[0].retry.backoff", Message: `must be one of constant, linear, exponential, fibonacci, fullJitter, equalJitter or decorrelatedJitter, got "quadratic"`},
+		{Path: "policies.payments[1].circuitBreaker.breakDuration", Message: `invalid duration "soon"`},
+		{Path: "policies.payments[1].circuitBreaker.failureRate.threshold", Message: "must be greater than 0 and at most 1, got 1.5"},
+		{Path: "policies.payments[1].circuitBreaker.failureRate.samplingDuration", Message: "must be positive"},
+		{Path: "policies.payments[1].circuitBreaker.failureRate.minimumThroughput", Message: "must be at least 1, got 0"},
+		{Path: "policies.search[0].timeout.strategy", Message: `must be one of optimistic or pessimistic, got "eager"`},
+		{Path: "policies.search[0]", Message: "must be exactly one of retry, circuitBreaker, timeout or bulkhead, got timeout, bulkhead"},
+	}, validationErr.Errors)
+	assert.Contains(test.T(), err.Error(), "invalid policy config: policies.empty: must contain at least one step; ")
+}
+
+func (test *PolicyConfigSuite) TestLoadRejectsRetriesBelowDurations() {
+	_, err := newLoader().Load([]byte(`{"policies": {"payments": [{"retry": {"retries": 1, "durations": ["1s", "2s"], "backoff": "constant"}}]}}`))
+
+	assert.Equal(test.T(), policyconfig.ValidationError{Errors: []policyconfig.FieldError{
+		{Path: "policies.payments[0].retry.retries", Message: "must not be less than the 2 durations, got 1"},
+		{Path: "policies.payments[0].retry.durations", Message: "must not be given together with a backoff"},
+	}}, err)
+}
+
+func (test *PolicyConfigSuite) TestLoadRejectsDelaysNotUsedByBackoff() {
+	_, err := newLoader().Load([]byte(`
+policies:
+  withoutBackoff:
+    - retry:
+        delay: 1s
+  constant:
+    - retry:
+        backoff: constant
+        delay: 1s
+        step: 1s
+        maxDelay: 2s
+  exponential:
+    - retry:
+        backoff: exponential
+        delay: 1s
+        step: 1s
+        maxDelay: 2s
+  durations:
+    - retry:
+        durations: [1s]
+        maxDelay: 2s
+`))
+
+	assert.Equal(test.T(), policyconfig.ValidationError{Errors: []policyconfig.FieldError{
+		{Path: "policies.constant[0].retry.step", Message: "is not used by the constant backoff"},
+		{Path: "policies.constant[0].retry.maxDelay", Message: "is not used by the constant backoff"},
+		{Path: "policies.durations[0].retry.maxDelay", Message: "must not be given together with durations"},
+		{Path: "policies.exponential[0].retry.step", Message: "is not used by the exponential backoff"},
+		{Path: "policies.withoutBackoff[0].retry.delay", Message: "must not be given without a backoff"},
+	}}, err)
+}
+
+func (test *PolicyConfigSuite) TestLoadRejectsHandlingOfPoliciesHandlingNothing() {
+	_, err := newLoader().Load([]byte(`
+policies:
+  search:
+    - timeout:
+        timeout: 1s
+        handle: [transient]
+    - bulkhead:
+        maxParallelism: 1
+        handleResult: [empty]
+`))
+
+	assert.Equal(test.T(), policyconfig.ValidationError{Errors: []policyconfig.FieldError{
+		{Path: "policies.search[0].timeout.handle", Message: "must not be given for a timeout"},
+		{Path: "policies.search[1].bulkhead.handleResult", Message: "must not be given for a bulkhead"},
+	}}, err)
+}
+
+func (test *PolicyConfigSuite) TestBuildFailsWithoutConfig() {
+	policies, err := newLoader().Build(nil)
+
+	assert.Nil(test.T(), policies)
+	assert.NotNil(test.T(), err)
+}
+
+func (test *PolicyConfigSuite) TestLoadIntoRegistersPolicies() {
+	registry := policy.NewRegistry()
+
+	err := newLoader().LoadInto(registry, []byte(`
+policies:
+  payments:
+    - circuitBreaker:
+        maxErrors: 3
+  search:
+    - timeout: {}
+`))
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), []string{"payments", "search"}, registry.Names())
+	assert.Len(test.T(), registry.CircuitBreakers(), 1)
+}
+
+func (test *PolicyConfigSuite) TestLoadIntoRegistersNothingForInvalidDocuments() {
+	registry := policy.NewRegistry()
+
+	err := newLoader().LoadInto(registry, []byte(`
+policies:
+  payments:
+    - circuitBreaker:
+        maxErrors: 3
+  search:
+    - timeout:
+        timeout: -1s
+`))
+
+	assert.Equal(test.T(), policyconfig.ValidationError{Errors: []policyconfig.FieldError{
+		{Path: "policies.search[0].timeout.timeout", Message: "must not be negative, got -1s"},
+	}}, err)
+	assert.Empty(test.T(), registry.Names())
+}
+
+func (test *PolicyConfigSuite) TestLoadIntoRegistersNothingForTakenNames() {
+	registry := policy.NewRegistry()
+	taken := policy.DefaultRetryPolicy()
+	_ = registry.Register("search", taken)
+
+	err := newLoader().LoadInto(registry, []byte(`
+policies:
+  payments:
+    - circuitBreaker:
+        maxErrors: 3
+  search:
+    - timeout: {}
+`))
+
+	assert.Equal(test.T(), policy.PolicyAlreadyRegisteredError{Name: "search"}, err)
+	assert.Equal(test.T(), []string{"search"}, registry.Names(), "policies registered although a name is taken")
+	plcy, _ := registry.Get("search")
+	assert.True(test.T(), plcy == taken, "registered policy replaced")
+}
@@ -0,0 +1,15 @@
+package policyconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PolicyConfigSuite struct {
+	suite.Suite
+}
+
+func TestPolicyConfig(t *testing.T) {
+	suite.Run(t, new(PolicyConfigSuite))
+}
@@ -0,0 +1,46 @@
+package policygrpc
+
+import (
+	"github.com/typusomega/poligo/pkg/policy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HandleRetryable is the entrypoint to build policies handling failed calls worth retrying
+// See RetryableCodes for the handled status codes.
+func HandleRetryable(idempotent bool) policy.Builder {
+	return HandleCodes(RetryableCodes(idempotent)...)
+}
+
+// HandleCodes is the entrypoint to build policies handling failed calls with one of the given status codes
+func HandleCodes(handled ...codes.Code) policy.Builder {
+	return policy.Handle(HasCode(handled...))
+}
+
+// HasCode returns a HandlePredicate handling errors with one of the given status codes
+func HasCode(handled ...codes.Code) policy.HandlePredicate {
+	return func(err error) bool {
+		if err == nil {
+			return false
+		}
+
+		code := status.Code(err)
+		for _, c := range handled {
+			if code == c {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RetryableCodes returns the status codes of failed calls worth retrying, Unavailable and ResourceExhausted
+// DeadlineExceeded is only contained for idempotent calls, as the server might have completed the call anyway.
+func RetryableCodes(idempotent bool) []codes.Code {
+	retryable := []codes.Code{codes.Unavailable, codes.ResourceExhausted}
+	if idempotent {
+		retryable = append(retryable, codes.DeadlineExceeded)
+	}
+
+	return retryable
+}
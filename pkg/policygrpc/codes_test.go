@@ -0,0 +1,35 @@
+package policygrpc_test
+
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policygrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (test *PolicyGRPCSuite) TestHasCodeHandlesGivenCodes() {
+	predicate := policygrpc.HasCode(codes.Unavailable, codes.Aborted)
+
+	assert.True(test.T(), predicate(status.Error(codes.Unavailable, "unavailable")))
+	assert.True(test.T(), predicate(fmt.Errorf("wrapped: %w", status.Error(codes.Aborted, "aborted"))), "wrapped status not handled")
+	assert.False(test.T(), predicate(status.Error(codes.NotFound, "not found")))
+	assert.False(test.T(), predicate(fmt.Errorf("fail")))
+	assert.False(test.T(), predicate(nil))
+}
+
+func (test *PolicyGRPCSuite) TestRetryableCodesContainDeadlineExceededOnlyForIdempotentCalls() {
+	assert.Equal(test.T(), []codes.Code{codes.Unavailable, codes.ResourceExhausted}, policygrpc.RetryableCodes(false))
+	assert.Equal(test.T(), []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded}, policygrpc.RetryableCodes(true))
+}
+
+func (test *PolicyGRPCSuite) TestHandleRetryableHandlesRetryableCodes() {
+	plcy := policygrpc.HandleRetryable(false).Retry()
+	idempotent := policygrpc.HandleRetryable(true).Retry()
+
+	assert.True(test.T(), plcy.ShouldHandle(status.Error(codes.ResourceExhausted, "exhausted")))
+	assert.False(test.T(), plcy.ShouldHandle(status.Error(codes.DeadlineExceeded, "deadline")))
+	assert.True(test.T(), idempotent.ShouldHandle(status.Error(codes.DeadlineExceeded, "deadline")))
+	assert.False(test.T(), idempotent.ShouldHandle(status.Error(codes.InvalidArgument, "invalid")))
+}
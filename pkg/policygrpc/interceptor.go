@@ -0,0 +1,148 @@
+// Package policygrpc applies policies to gRPC clients
+package policygrpc
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/typusomega/poligo/pkg/policy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AttemptMetadataKey is the key of the outgoing metadata telling the server the number of the call's attempt
+const AttemptMetadataKey = "poligo-attempt"
+
+// UnaryClientInterceptor returns an interceptor running every unary call through the given policy
+// Once retries are exhausted a policy.RetryExhaustedError is returned, status.Code reports the last attempt's code for it.
+func UnaryClientInterceptor(p policy.Policy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return p.ExecuteVoidContext(ctx, func(ctx context.Context) error {
+			return invoker(withAttemptMetadata(ctx), method, req, reply, cc, opts...)
+		})
+	}
+}
+
+// StreamClientInterceptor returns an interceptor running the establishment of every stream through the given policy
+// Messages sent and received on an established stream are not covered by the policy,
+// an established stream is only bound to the call's context rather than the one of its attempt.
+// Streams discarded by the policy are cancelled.
+func StreamClientInterceptor(p policy.Policy) grpc.StreamClientInterceptor {
+	return func(callCtx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		established := &streamTracker{}
+		val, err := p.ExecuteContext(callCtx, func(ctx context.Context) (interface{}, error) {
+			stream, cancel, err := establish(ctx, callCtx, func(ctx context.Context) (grpc.ClientStream, error) {
+				return streamer(withAttemptMetadata(ctx), desc, cc, method, opts...)
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			established.track(stream, cancel)
+			return stream, nil
+		})
+
+		stream, ok := val.(grpc.ClientStream)
+		if err != nil || !ok {
+			established.finish(nil)
+		} else {
+			established.finish(stream)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, status.Errorf(codes.Internal, "policygrpc: policy returned %T instead of grpc.ClientStream", val)
+		}
+		return stream, nil
+	}
+}
+
+// establish opens a stream, cancelling it if the attempt's context is done before the stream is established
+// The attempt's context may be cancelled as soon as the attempt returned, e.g. by a TimeoutPolicy or an attempt timeout,
+// so afterwards the stream is only bound to the call's context.
+func establish(ctx, callCtx context.Context, open func(ctx context.Context) (grpc.ClientStream, error)) (grpc.ClientStream, context.CancelFunc, error) {
+	streamCtx, cancel := context.WithCancel(streamContext{Context: ctx, call: callCtx})
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			select {
+			case <-done:
+			default:
+				cancel()
+			}
+		case <-done:
+		}
+	}()
+
+	stream, err := open(streamCtx)
+	close(done)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	return stream, cancel, nil
+}
+
+// streamContext carries the values of an attempt's context but is only cancelled along with the call's context
+type streamContext struct {
+	context.Context
+	call context.Context
+}
+
+func (it streamContext) Deadline() (time.Time, bool) { return it.call.Deadline() }
+func (it streamContext) Done() <-chan struct{}       { return it.call.Done() }
+func (it streamContext) Err() error                  { return it.call.Err() }
+
+// streamTracker cancels the streams established by a call's attempts but discarded by the policy
+// Streams established after the call finished, e.g. by attempts abandoned by a pessimistic TimeoutPolicy,
+// are cancelled right away.
+type streamTracker struct {
+	mux      sync.Mutex
+	streams  []grpc.ClientStream
+	cancels  []context.CancelFunc
+	finished bool
+}
+
+func (it *streamTracker) track(stream grpc.ClientStream, cancel context.CancelFunc) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	if it.finished {
+		cancel()
+		return
+	}
+	it.streams = append(it.streams, stream)
+	it.cancels = append(it.cancels, cancel)
+}
+
+// finish cancels all streams but the returned one
+func (it *streamTracker) finish(returned grpc.ClientStream) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	for i, stream := range it.streams {
+		if stream != returned {
+			it.cancels[i]()
+		}
+	}
+	it.streams, it.cancels = nil, nil
+	it.finished = true
+}
+
+// withAttemptMetadata adds the number of the attempt to the outgoing metadata
+func withAttemptMetadata(ctx context.Context) context.Context {
+	attempt, ok := policy.AttemptFromContext(ctx)
+	if !ok {
+		attempt = policy.Attempt{Number: 1}
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, AttemptMetadataKey, strconv.Itoa(attempt.Number))
+}
@@ -0,0 +1,235 @@
+package policygrpc_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/policygrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// flakyHealthServer fails the first failures checks with the given code, recording the attempt metadata of all calls
+// Checks are failed with the failureCodes in order instead, if given.
+type flakyHealthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	failures     int
+	code         codes.Code
+	failureCodes []codes.Code
+
+	mux      sync.Mutex
+	attempts []string
+}
+
+func (it *flakyHealthServer) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	check := it.record(ctx)
+	if check <= len(it.failureCodes) {
+		return nil, status.Error(it.failureCodes[check-1], "failed")
+	}
+	if check <= it.failures {
+		return nil, status.Error(it.code, "failed")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (it *flakyHealthServer) Watch(_ *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	it.record(stream.Context())
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+func (it *flakyHealthServer) record(ctx context.Context) int {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	it.attempts = append(it.attempts, md.Get(policygrpc.AttemptMetadataKey)...)
+	return len(it.attempts)
+}
+
+func (it *flakyHealthServer) recordedAttempts() []string {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return append([]string{}, it.attempts...)
+}
+
+// dial serves the health server in-process and returns a client connection using the given interceptors
+func (test *PolicyGRPCSuite) dial(server healthpb.HealthServer, opts ...grpc.DialOption) healthpb.HealthClient {
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(listener) }()
+	test.T().Cleanup(grpcServer.Stop)
+
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial("bufnet", opts...)
+	assert.Nil(test.T(), err)
+	test.T().Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func (test *PolicyGRPCSuite) TestUnaryInterceptorRetriesRetryableCodes() {
+	server := &flakyHealthServer{failures: 2, code: codes.Unavailable}
+	retry := policygrpc.HandleRetryable(false).Retry(policy.WithRetries(3))
+	client := test.dial(server, grpc.WithUnaryInterceptor(policygrpc.UnaryClientInterceptor(retry)))
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), healthpb.HealthCheckResponse_SERVING, resp.Status)
+	assert.Equal(test.T(), []string{"1", "2", "3"}, server.recordedAttempts(), "attempt count not sent as metadata")
+}
+
+func (test *PolicyGRPCSuite) TestUnaryInterceptorDoesNotRetryOtherCodes() {
+	server := &flakyHealthServer{failures: 1, code: codes.InvalidArgument}
+	retry := policygrpc.HandleRetryable(true).Retry(policy.WithRetries(3))
+	client := test.dial(server, grpc.WithUnaryInterceptor(policygrpc.UnaryClientInterceptor(retry)))
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	assert.Equal(test.T(), codes.InvalidArgument, status.Code(err))
+	assert.Len(test.T(), server.recordedAttempts(), 1, "unhandled code retried")
+}
+
+func (test *PolicyGRPCSuite) TestUnaryInterceptorKeepsStatusCodeWhenRetriesExhausted() {
+	server := &flakyHealthServer{failures: 10, code: codes.ResourceExhausted}
+	retry := policygrpc.HandleRetryable(false).Retry(policy.WithRetries(1))
+	client := test.dial(server, grpc.WithUnaryInterceptor(policygrpc.UnaryClientInterceptor(retry)))
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	assert.IsType(test.T(), policy.RetryExhaustedError{}, err)
+	assert.Equal(test.T(), codes.ResourceExhausted, status.Code(err))
+}
+
+func (test *PolicyGRPCSuite) TestUnaryInterceptorReturnsStatusCodeOfLastAttempt() {
+	server := &flakyHealthServer{failureCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded}}
+	retry := policygrpc.HandleRetryable(true).Retry(policy.WithRetries(2), policy.WithDurations(time.Nanosecond))
+	client := test.dial(server, grpc.WithUnaryInterceptor(policygrpc.UnaryClientInterceptor(retry)))
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	assert.Len(test.T(), server.recordedAttempts(), 3)
+	assert.Equal(test.T(), codes.DeadlineExceeded, status.Code(err), "status code of an earlier attempt returned")
+}
+
+func (test *PolicyGRPCSuite) TestUnaryInterceptorBreaksCircuit() {
+	server := &flakyHealthServer{failures: 1, code: codes.Unavailable}
+	circuitBreaker := policygrpc.HandleRetryable(false).WithCircuitBreaker()
+	circuitBreaker.BrokenForProvider = func(int) (time.Duration, bool) { return time.Hour, true }
+	client := test.dial(server, grpc.WithUnaryInterceptor(policygrpc.UnaryClientInterceptor(circuitBreaker)))
+
+	_, _ = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	assert.Equal(test.T(), policy.CircuitBrokenError{}, err)
+	assert.Len(test.T(), server.recordedAttempts(), 1, "call passed broken circuit")
+}
+
+func (test *PolicyGRPCSuite) TestStreamInterceptorEstablishesStream() {
+	server := &flakyHealthServer{}
+	retry := policygrpc.HandleRetryable(false).Retry()
+	client := test.dial(server, grpc.WithStreamInterceptor(policygrpc.StreamClientInterceptor(retry)))
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Nil(test.T(), err)
+	resp, err := stream.Recv()
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), healthpb.HealthCheckResponse_SERVING, resp.Status)
+	assert.Equal(test.T(), []string{"1"}, server.recordedAttempts(), "attempt count not sent as metadata")
+}
+
+func (test *PolicyGRPCSuite) TestStreamOutlivesAttemptTimeout() {
+	server := &flakyHealthServer{}
+	retry := policygrpc.HandleRetryable(false).Retry(policy.WithAttemptTimeout(time.Millisecond * 20))
+	client := test.dial(server, grpc.WithStreamInterceptor(policygrpc.StreamClientInterceptor(retry)))
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Nil(test.T(), err)
+	time.Sleep(time.Millisecond * 40)
+	resp, err := stream.Recv()
+
+	assert.Nil(test.T(), err, "stream cancelled along with its attempt")
+	assert.Equal(test.T(), healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+func (test *PolicyGRPCSuite) TestStreamIsCancelledAlongWithCall() {
+	server := &flakyHealthServer{}
+	retry := policygrpc.HandleRetryable(false).Retry(policy.WithAttemptTimeout(time.Second))
+	client := test.dial(server, grpc.WithStreamInterceptor(policygrpc.StreamClientInterceptor(retry)))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	assert.Nil(test.T(), err)
+	cancel()
+	_, err = stream.Recv()
+
+	assert.Equal(test.T(), codes.Canceled, status.Code(err))
+}
+
+func (test *PolicyGRPCSuite) TestStreamInterceptorRetriesEstablishingStream() {
+	attempts := []string{}
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		attempts = append(attempts, md.Get(policygrpc.AttemptMetadataKey)...)
+		if len(attempts) == 1 {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		}
+		return fakeStream{}, nil
+	}
+	interceptor := policygrpc.StreamClientInterceptor(policygrpc.HandleRetryable(false).Retry())
+
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/test", streamer)
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), fakeStream{}, stream)
+	assert.Equal(test.T(), []string{"1", "2"}, attempts)
+}
+
+func (test *PolicyGRPCSuite) TestStreamInterceptorFailsOnResultOtherThanStream() {
+	fallback := policy.HandleAll().Fallback(policy.WithFallbackValue("fallback"))
+	streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+
+	stream, err := policygrpc.StreamClientInterceptor(fallback)(context.Background(), &grpc.StreamDesc{}, nil, "/test", streamer)
+
+	assert.Nil(test.T(), stream)
+	assert.Equal(test.T(), codes.Internal, status.Code(err))
+}
+
+func (test *PolicyGRPCSuite) TestStreamInterceptorCancelsDiscardedStreams() {
+	contexts := []context.Context{}
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		contexts = append(contexts, ctx)
+		return &fakeStream{}, nil
+	}
+	retry := policy.HandleAll().Retry(policy.WithPredicates(func(interface{}) bool { return true }))
+	retry.ShouldHandle = func(error) bool { return true }
+
+	_, err := policygrpc.StreamClientInterceptor(retry)(context.Background(), &grpc.StreamDesc{}, nil, "/test", streamer)
+
+	assert.Nil(test.T(), err)
+	assert.Len(test.T(), contexts, 2)
+	assert.NotNil(test.T(), contexts[0].Err(), "discarded stream not cancelled")
+	assert.Nil(test.T(), contexts[1].Err(), "returned stream cancelled")
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+}
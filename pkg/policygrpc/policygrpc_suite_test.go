@@ -0,0 +1,15 @@
+package policygrpc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PolicyGRPCSuite struct {
+	suite.Suite
+}
+
+func TestPolicyGRPC(t *testing.T) {
+	suite.Run(t, new(PolicyGRPCSuite))
+}
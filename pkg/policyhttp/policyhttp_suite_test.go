@@ -0,0 +1,15 @@
+package policyhttp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PolicyHTTPSuite struct {
+	suite.Suite
+}
+
+func TestPolicyHTTP(t *testing.T) {
+	suite.Run(t, new(PolicyHTTPSuite))
+}
@@ -0,0 +1,38 @@
+package policyhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// HandleTransient is the entrypoint to build policies handling transient failures of round trips,
+// i.e. connection errors and responses with a 5xx or 429 status
+func HandleTransient() policy.Builder {
+	return policy.Handle(IsTransientError).OrResult(IsTransientResponse)
+}
+
+// IsTransientError reports whether the error of a round trip is a connection error, which is worth retrying
+// Cancelled requests are not transient.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsTransientResponse reports whether the result of a round trip is a response with a 5xx or 429 status
+func IsTransientResponse(val interface{}) bool {
+	resp, ok := val.(*http.Response)
+	if !ok || resp == nil {
+		return false
+	}
+
+	return resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+}
@@ -0,0 +1,32 @@
+package policyhttp_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policyhttp"
+)
+
+func (test *PolicyHTTPSuite) TestIsTransientErrorClassifiesConnectionErrors() {
+	assert.True(test.T(), policyhttp.IsTransientError(&net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}))
+	assert.True(test.T(), policyhttp.IsTransientError(fmt.Errorf("reading response: %w", io.ErrUnexpectedEOF)))
+	assert.True(test.T(), policyhttp.IsTransientError(io.EOF))
+	assert.False(test.T(), policyhttp.IsTransientError(nil))
+	assert.False(test.T(), policyhttp.IsTransientError(context.Canceled), "cancelled request is transient")
+	assert.False(test.T(), policyhttp.IsTransientError(fmt.Errorf("malformed request")))
+}
+
+func (test *PolicyHTTPSuite) TestIsTransientResponseClassifiesStatusCodes() {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		assert.True(test.T(), policyhttp.IsTransientResponse(&http.Response{StatusCode: status}), "status %d not transient", status)
+	}
+	for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusBadRequest} {
+		assert.False(test.T(), policyhttp.IsTransientResponse(&http.Response{StatusCode: status}), "status %d transient", status)
+	}
+	assert.False(test.T(), policyhttp.IsTransientResponse(nil))
+	assert.False(test.T(), policyhttp.IsTransientResponse("503"))
+}
@@ -0,0 +1,280 @@
+// Package policyhttp applies policies to HTTP clients
+package policyhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// Transport is an http.RoundTripper applying a policy to every round trip of its base RoundTripper
+type Transport struct {
+	base            http.RoundTripper
+	policy          policy.Policy
+	retryAllMethods bool
+}
+
+// NewTransport creates a Transport applying the given policy to every round trip of the base RoundTripper
+// A nil base uses http.DefaultTransport.
+// By default only idempotent requests are retried: all others are executed with a context made by policy.WithoutRetries,
+// so every RetryPolicy of the policy gives up after the first attempt while all other policies apply as usual.
+// Policies making further attempts regardless get an error instead of sending the request again.
+func NewTransport(base http.RoundTripper, p policy.Policy, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	transport := &Transport{
+		base:   base,
+		policy: p,
+	}
+
+	for _, opt := range opts {
+		opt(transport)
+	}
+
+	return transport
+}
+
+// Option modifies the Transport
+type Option func(*Transport)
+
+// WithNonIdempotentRetries makes the Transport retry requests no matter their method
+// Requests with a body are only retried if the body can be rewound using GetBody
+func WithNonIdempotentRetries() Option {
+	return func(o *Transport) {
+		o.retryAllMethods = true
+	}
+}
+
+// RoundTrip executes the round trip applying the policy
+// Every attempt sends a fresh copy of the request, its body rewound using GetBody.
+// Responses discarded by the policy are closed, like the request's body if the policy rejected it before any attempt.
+func (it *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if it.policy == nil {
+		return it.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	retries := it.retries(req)
+	if !retries {
+		ctx = policy.WithoutRetries(ctx)
+	}
+
+	attempts := &attemptTracker{}
+	val, err := it.policy.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		attempt := attempts.next()
+		if attempt > 0 && !retries {
+			return nil, fmt.Errorf("policyhttp: %s request not retried", req.Method)
+		}
+
+		attemptReq, err := it.attemptRequest(ctx, req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := it.roundTrip(ctx, req.Context(), attemptReq)
+		attempts.track(resp)
+		return resp, err
+	})
+	if !attempts.started() && req.Body != nil {
+		// the base RoundTripper never got the request, so its body is closed here as the RoundTripper contract demands
+		_ = req.Body.Close()
+	}
+
+	resp, ok := val.(*http.Response)
+	if val != nil && !ok {
+		attempts.finish(nil)
+		return nil, fmt.Errorf("policyhttp: policy returned %T instead of *http.Response", val)
+	}
+	if err != nil && !gaveUpOn(resp, err) {
+		attempts.finish(nil)
+		closeBody(resp)
+		return nil, err
+	}
+	attempts.finish(resp)
+	if resp == nil {
+		return nil, fmt.Errorf("policyhttp: policy returned neither a response nor an error")
+	}
+
+	return resp, nil
+}
+
+// gaveUpOn reports whether the policy gave up retrying because of the given handled response
+// An http.Client expects that response rather than an error, like without retries.
+func gaveUpOn(resp *http.Response, err error) bool {
+	var exhausted policy.RetryExhaustedError
+	if resp == nil || !errors.As(err, &exhausted) {
+		return false
+	}
+
+	handled, ok := exhausted.Last().(policy.HandledResultError)
+	return ok && handled.Result == resp
+}
+
+// retries reports whether the request may be retried
+func (it *Transport) retries(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	return it.retryAllMethods || isIdempotent(req)
+}
+
+// attemptRequest copies the request for an attempt, rewinding its body for every attempt but the first one
+func (it *Transport) attemptRequest(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	attemptReq := req.Clone(ctx)
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return attemptReq, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("policyhttp: rewinding request body: %w", err)
+	}
+	attemptReq.Body = body
+
+	return attemptReq, nil
+}
+
+// roundTrip sends the request, cancelling it if the attempt's context is done before the response arrived
+// The attempt's context may be cancelled as soon as the attempt returned, e.g. by a TimeoutPolicy,
+// so afterwards the response's body is only bound to the original request's context.
+func (it *Transport) roundTrip(ctx, parent context.Context, req *http.Request) (*http.Response, error) {
+	reqCtx, cancel := context.WithCancel(detachedContext{ctx})
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			select {
+			case <-done:
+			default:
+				cancel()
+				return
+			}
+		case <-done:
+		}
+
+		select {
+		case <-parent.Done():
+			cancel()
+		case <-reqCtx.Done():
+		}
+	}()
+
+	resp, err := it.base.RoundTrip(req.WithContext(reqCtx))
+	close(done)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// detachedContext carries the values of its parent but is never cancelled
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// cancelOnClose releases the context of a request once its response's body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (it *cancelOnClose) Close() error {
+	err := it.ReadCloser.Close()
+	it.cancel()
+	return err
+}
+
+// attemptTracker counts the attempts of a round trip and closes the responses discarded by the policy
+// Responses arriving after the round trip finished, e.g. of attempts abandoned by a pessimistic TimeoutPolicy,
+// are closed right away.
+type attemptTracker struct {
+	mux      sync.Mutex
+	attempts int
+	last     *http.Response
+	finished bool
+}
+
+// next discards the response of the previous attempt and returns the number of attempts made so far
+func (it *attemptTracker) next() int {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	closeBody(it.last)
+	it.last = nil
+	it.attempts++
+
+	return it.attempts - 1
+}
+
+// started reports whether any attempt was made
+func (it *attemptTracker) started() bool {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return it.attempts > 0
+}
+
+func (it *attemptTracker) track(resp *http.Response) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	if it.finished {
+		closeBody(resp)
+		return
+	}
+	it.last = resp
+}
+
+// finish closes the last attempt's response unless it is the one returned
+func (it *attemptTracker) finish(returned *http.Response) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	if it.last != returned {
+		closeBody(it.last)
+	}
+	it.last = nil
+	it.finished = true
+}
+
+// closeBody drains and closes the body of a discarded response, so its connection can be reused
+func closeBody(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBytes))
+	_ = resp.Body.Close()
+}
+
+// maxDrainedBytes is the number of bytes read at most from a discarded response's body
+const maxDrainedBytes = 4 << 10
+
+// isIdempotent reports whether the request may be sent several times without changing its effect
+// Like in net/http, requests with an Idempotency-Key header are considered idempotent
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	_, hasKey := req.Header["Idempotency-Key"]
+	_, hasXKey := req.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
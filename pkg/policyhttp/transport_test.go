@@ -0,0 +1,379 @@
+package policyhttp_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/metrics"
+	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/policyhttp"
+)
+
+// flakyServer answers the first failures requests with 503 and all others with 200, recording the request bodies
+type flakyServer struct {
+	*httptest.Server
+
+	mux      sync.Mutex
+	failures int
+	bodies   []string
+}
+
+func newFlakyServer(failures int) *flakyServer {
+	server := &flakyServer{failures: failures}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		server.mux.Lock()
+		server.bodies = append(server.bodies, string(body))
+		failed := len(server.bodies) <= server.failures
+		server.mux.Unlock()
+
+		if failed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("unavailable"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	return server
+}
+
+func (it *flakyServer) requests() []string {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return append([]string{}, it.bodies...)
+}
+
+func retryTransient(retries int) *policy.RetryPolicy {
+	return policyhttp.HandleTransient().Retry(policy.WithRetries(retries))
+}
+
+func (test *PolicyHTTPSuite) TestRetriesTransientResponses() {
+	server := newFlakyServer(2)
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, retryTransient(3))}
+
+	resp, err := client.Get(server.URL)
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(test.T(), "ok", string(body))
+	assert.Len(test.T(), server.requests(), 3, "transient responses not retried")
+}
+
+func (test *PolicyHTTPSuite) TestReturnsLastResponseWhenRetriesExhausted() {
+	server := newFlakyServer(10)
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, retryTransient(1))}
+
+	resp, err := client.Get(server.URL)
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), http.StatusServiceUnavailable, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Nil(test.T(), err, "returned response's body closed")
+	assert.Equal(test.T(), "unavailable", string(body))
+	assert.Len(test.T(), server.requests(), 2)
+}
+
+func (test *PolicyHTTPSuite) TestRewindsRequestBodyBetweenAttempts() {
+	server := newFlakyServer(1)
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, retryTransient(1))}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, bytes.NewReader([]byte("payload")))
+	resp, err := client.Do(req)
+
+	assert.Nil(test.T(), err)
+	_ = resp.Body.Close()
+	assert.Equal(test.T(), []string{"payload", "payload"}, server.requests(), "request body not rewound")
+}
+
+func (test *PolicyHTTPSuite) TestDoesNotRetryNonIdempotentRequestsByDefault() {
+	server := newFlakyServer(1)
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, retryTransient(1))}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+
+	assert.Nil(test.T(), err)
+	_ = resp.Body.Close()
+	assert.Equal(test.T(), http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Len(test.T(), server.requests(), 1, "non-idempotent request retried")
+}
+
+func (test *PolicyHTTPSuite) TestAppliesRemainingPoliciesToNonIdempotentRequests() {
+	server := newFlakyServer(1)
+	defer server.Close()
+	circuitBreaker := policyhttp.HandleTransient().WithCircuitBreaker()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, policy.Wrap(retryTransient(1), circuitBreaker))}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+
+	assert.Nil(test.T(), err)
+	_ = resp.Body.Close()
+	assert.Len(test.T(), server.requests(), 1, "non-idempotent request retried")
+	assert.Equal(test.T(), policy.CircuitOpen, circuitBreaker.State(), "circuit breaker not applied")
+}
+
+// opaquePolicy hides the type of the policy it applies
+type opaquePolicy struct {
+	policy.Policy
+}
+
+func (test *PolicyHTTPSuite) TestDoesNotRetryNonIdempotentRequestsWithinOpaquePolicies() {
+	server := newFlakyServer(10)
+	defer server.Close()
+	retry := policyhttp.HandleTransient().Retry(policy.WithRetries(3), policy.WithDurations(time.Nanosecond))
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, opaquePolicy{retry})}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+
+	assert.Nil(test.T(), err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(test.T(), http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(test.T(), "unavailable", string(body), "first response not returned")
+	assert.Len(test.T(), server.requests(), 1, "non-idempotent request retried")
+}
+
+func (test *PolicyHTTPSuite) TestDoesNotRetryNonIdempotentRequestsWithinInstrumentedPolicies() {
+	server := newFlakyServer(10)
+	defer server.Close()
+	retry := policyhttp.HandleTransient().Retry(policy.WithRetries(3), policy.WithDurations(time.Hour))
+	circuitBreaker := policyhttp.HandleTransient().WithCircuitBreaker()
+	circuitBreaker.MaxErrors = 3
+	plcy := metrics.NewCollector().Instrument("x", policy.Wrap(retry, circuitBreaker))
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, plcy)}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+
+	assert.Nil(test.T(), err)
+	_ = resp.Body.Close()
+	assert.Equal(test.T(), http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Len(test.T(), server.requests(), 1, "non-idempotent request retried")
+	assert.Equal(test.T(), policy.CircuitClosed, circuitBreaker.State(), "circuit broken by attempts never made")
+}
+
+func (test *PolicyHTTPSuite) TestRetriesRequestsWithIdempotencyKey() {
+	server := newFlakyServer(1)
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, retryTransient(1))}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	req.Header.Set("Idempotency-Key", "key")
+	resp, err := client.Do(req)
+
+	assert.Nil(test.T(), err)
+	_ = resp.Body.Close()
+	assert.Equal(test.T(), []string{"payload", "payload"}, server.requests())
+}
+
+func (test *PolicyHTTPSuite) TestRetriesNonIdempotentRequestsIfConfigured() {
+	server := newFlakyServer(1)
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, retryTransient(1), policyhttp.WithNonIdempotentRetries())}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+
+	assert.Nil(test.T(), err)
+	_ = resp.Body.Close()
+	assert.Equal(test.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(test.T(), []string{"payload", "payload"}, server.requests())
+}
+
+func (test *PolicyHTTPSuite) TestDoesNotRetryRequestsWithoutRewindableBody() {
+	server := newFlakyServer(1)
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, retryTransient(1))}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, io.NopCloser(strings.NewReader("payload")))
+	resp, err := client.Do(req)
+
+	assert.Nil(test.T(), err)
+	_ = resp.Body.Close()
+	assert.Len(test.T(), server.requests(), 1, "request retried without being able to rewind its body")
+}
+
+func (test *PolicyHTTPSuite) TestRetriesConnectionErrors() {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}
+	base := &fakeRoundTripper{outcomes: []outcome{{err: connErr}, {status: http.StatusOK}}}
+	transport := policyhttp.NewTransport(base, retryTransient(1))
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(test.T(), 2, base.calls)
+}
+
+func (test *PolicyHTTPSuite) TestReturnsErrorWhenRetriesExhausted() {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}
+	base := &fakeRoundTripper{outcomes: []outcome{{err: connErr}, {err: connErr}}}
+	transport := policyhttp.NewTransport(base, retryTransient(1))
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	assert.Nil(test.T(), resp)
+	assert.IsType(test.T(), policy.RetryExhaustedError{}, err)
+}
+
+func (test *PolicyHTTPSuite) TestClosesDiscardedResponses() {
+	base := &fakeRoundTripper{outcomes: []outcome{{status: http.StatusBadGateway}, {status: http.StatusServiceUnavailable}, {status: http.StatusOK}}}
+	transport := policyhttp.NewTransport(base, retryTransient(2))
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), http.StatusOK, resp.StatusCode)
+	assert.True(test.T(), base.bodies[0].closed, "discarded response not closed")
+	assert.True(test.T(), base.bodies[1].closed, "discarded response not closed")
+	assert.False(test.T(), base.bodies[2].closed, "returned response closed")
+}
+
+func (test *PolicyHTTPSuite) TestClosesResponsesReplacedByFallback() {
+	base := &fakeRoundTripper{outcomes: []outcome{{status: http.StatusServiceUnavailable}}}
+	fallbackResp := &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}
+	fallback := policyhttp.HandleTransient().Fallback(policy.WithFallbackValue(fallbackResp))
+	transport := policyhttp.NewTransport(base, fallback)
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	assert.Nil(test.T(), err)
+	assert.Equal(test.T(), fallbackResp, resp)
+	assert.True(test.T(), base.bodies[0].closed, "replaced response not closed")
+}
+
+func (test *PolicyHTTPSuite) TestClosesRequestBodyRejectedBeforeAnyAttempt() {
+	circuitBreaker := policyhttp.HandleTransient().WithCircuitBreaker()
+	circuitBreaker.Isolate()
+	base := &fakeRoundTripper{outcomes: []outcome{{status: http.StatusOK}}}
+	transport := policyhttp.NewTransport(base, circuitBreaker)
+	body := &trackingBody{Reader: strings.NewReader("payload")}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	req.Body = body
+	_, err := transport.RoundTrip(req)
+
+	assert.NotNil(test.T(), err)
+	assert.True(test.T(), body.closed, "body of rejected request not closed")
+	assert.Equal(test.T(), 0, base.calls)
+}
+
+func (test *PolicyHTTPSuite) TestFailsOnResultOtherThanResponse() {
+	base := &fakeRoundTripper{outcomes: []outcome{{status: http.StatusServiceUnavailable}}}
+	fallback := policyhttp.HandleTransient().Fallback(policy.WithFallbackValue("fallback"))
+	transport := policyhttp.NewTransport(base, fallback)
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	assert.Nil(test.T(), resp)
+	assert.NotNil(test.T(), err, "unexpected result not reported")
+	assert.True(test.T(), base.bodies[0].closed, "replaced response not closed")
+}
+
+func (test *PolicyHTTPSuite) TestAttemptTimeoutCancelsHungRequests() {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	retry := policyhttp.HandleTransient().Retry(policy.WithAttemptTimeout(time.Millisecond * 50))
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, retry)}
+
+	resp, err := client.Get(server.URL)
+
+	assert.Nil(test.T(), err)
+	_ = resp.Body.Close()
+	assert.Equal(test.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(test.T(), int32(2), atomic.LoadInt32(&calls), "hung request not retried")
+}
+
+func (test *PolicyHTTPSuite) TestResponseBodyOutlivesTimeout() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		time.Sleep(time.Millisecond * 20)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, policy.HandleAll().Timeout(policy.WithTimeout(time.Second)))}
+
+	resp, err := client.Get(server.URL)
+	assert.Nil(test.T(), err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	assert.Nil(test.T(), err, "response body not readable after the timeout policy returned")
+	assert.Equal(test.T(), "ok", string(body))
+}
+
+func (test *PolicyHTTPSuite) TestCancelledRequestAbortsResponseBody() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: policyhttp.NewTransport(nil, policy.HandleAll().Timeout())}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.Nil(test.T(), err)
+	cancel()
+	_, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	assert.NotNil(test.T(), err, "cancelling the request did not abort reading the response body")
+}
+
+type outcome struct {
+	status int
+	err    error
+}
+
+// fakeRoundTripper returns the given outcomes one after another, recording the bodies of its responses
+type fakeRoundTripper struct {
+	outcomes []outcome
+	calls    int
+	bodies   []*trackingBody
+}
+
+func (it *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := it.outcomes[it.calls]
+	it.calls++
+	if next.err != nil {
+		return nil, next.err
+	}
+
+	body := &trackingBody{Reader: strings.NewReader("body")}
+	it.bodies = append(it.bodies, body)
+	return &http.Response{StatusCode: next.status, Body: body, Request: req}, nil
+}
+
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (it *trackingBody) Close() error {
+	it.closed = true
+	return nil
+}
@@ -0,0 +1,153 @@
+// Package policytest provides utilities for testing code built on policies
+package policytest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/typusomega/poligo/pkg/policy"
+)
+
+// NewFakeClock creates a FakeClock starting at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	clock := &FakeClock{now: now}
+	clock.cond = sync.NewCond(&clock.mux)
+	return clock
+}
+
+// FakeClock is a policy.Clock only moving forward when told to
+type FakeClock struct {
+	mux    sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// Now returns the clock's current time
+func (it *FakeClock) Now() time.Time {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return it.now
+}
+
+// NewTimer creates a Timer firing once the clock was advanced by the given duration
+func (it *FakeClock) NewTimer(d time.Duration) policy.Timer {
+	return it.addTimer(d, nil)
+}
+
+// AfterFunc calls f once the clock was advanced by the given duration
+// f is called synchronously by the Advance call moving the clock past its deadline
+func (it *FakeClock) AfterFunc(d time.Duration, f func()) policy.Timer {
+	return it.addTimer(d, f)
+}
+
+// Advance moves the clock forward and fires all timers due, in order of their deadlines
+func (it *FakeClock) Advance(d time.Duration) {
+	it.mux.Lock()
+	it.now = it.now.Add(d)
+	now := it.now
+
+	due := []*fakeTimer{}
+	pending := it.timers[:0]
+	for _, timer := range it.timers {
+		if timer.deadline.After(now) {
+			pending = append(pending, timer)
+		} else {
+			due = append(due, timer)
+		}
+	}
+	it.timers = pending
+	it.cond.Broadcast()
+	it.mux.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].deadline.Before(due[j].deadline) })
+	for _, timer := range due {
+		timer.fire(now)
+	}
+}
+
+// PendingTimers returns the number of timers not fired or stopped yet
+func (it *FakeClock) PendingTimers() int {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	return len(it.timers)
+}
+
+// WaitForTimers blocks until at least the given number of timers are pending
+// It is used to synchronize with policies waiting on the clock in another goroutine
+func (it *FakeClock) WaitForTimers(count int) {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	for len(it.timers) < count {
+		it.cond.Wait()
+	}
+}
+
+// addTimer registers a timer, timers without positive duration fire right away just like the ones of the time package
+func (it *FakeClock) addTimer(d time.Duration, f func()) *fakeTimer {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	timer := &fakeTimer{
+		clock:    it,
+		deadline: it.now.Add(d),
+		c:        make(chan time.Time, 1),
+		f:        f,
+	}
+
+	if d <= 0 {
+		if f != nil {
+			go f()
+		} else {
+			timer.c <- it.now
+		}
+		return timer
+	}
+
+	it.timers = append(it.timers, timer)
+	it.cond.Broadcast()
+
+	return timer
+}
+
+func (it *FakeClock) removeTimer(timer *fakeTimer) bool {
+	it.mux.Lock()
+	defer it.mux.Unlock()
+
+	for i, t := range it.timers {
+		if t == timer {
+			it.timers = append(it.timers[:i], it.timers[i+1:]...)
+			it.cond.Broadcast()
+			return true
+		}
+	}
+
+	return false
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	c        chan time.Time
+	f        func()
+}
+
+func (it *fakeTimer) C() <-chan time.Time {
+	return it.c
+}
+
+func (it *fakeTimer) Stop() bool {
+	return it.clock.removeTimer(it)
+}
+
+func (it *fakeTimer) fire(now time.Time) {
+	if it.f != nil {
+		it.f()
+		return
+	}
+	it.c <- now
+}
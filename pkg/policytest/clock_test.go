@@ -0,0 +1,74 @@
+package policytest_test
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policytest"
+)
+
+var start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (test *PolicyTestSuite) TestFakeClockOnlyMovesWhenAdvanced() {
+	clock := policytest.NewFakeClock(start)
+
+	assert.Equal(test.T(), start, clock.Now())
+	clock.Advance(time.Minute)
+	assert.Equal(test.T(), start.Add(time.Minute), clock.Now())
+}
+
+func (test *PolicyTestSuite) TestTimerFiresWhenDeadlineIsReached() {
+	clock := policytest.NewFakeClock(start)
+	timer := clock.NewTimer(time.Minute)
+
+	clock.Advance(time.Second * 59)
+	select {
+	case <-timer.C():
+		test.T().Fatal("timer fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	assert.Equal(test.T(), start.Add(time.Minute), <-timer.C())
+	assert.Equal(test.T(), 0, clock.PendingTimers())
+}
+
+func (test *PolicyTestSuite) TestStoppedTimerDoesNotFire() {
+	called := false
+	clock := policytest.NewFakeClock(start)
+	timer := clock.AfterFunc(time.Minute, func() { called = true })
+
+	assert.True(test.T(), timer.Stop(), "pending timer not stopped")
+	assert.False(test.T(), timer.Stop(), "timer stopped twice")
+	clock.Advance(time.Hour)
+
+	assert.False(test.T(), called, "stopped timer fired")
+}
+
+func (test *PolicyTestSuite) TestAfterFuncsAreCalledInOrder() {
+	calls := []int{}
+	clock := policytest.NewFakeClock(start)
+	clock.AfterFunc(time.Minute*2, func() { calls = append(calls, 2) })
+	clock.AfterFunc(time.Minute, func() { calls = append(calls, 1) })
+
+	clock.Advance(time.Hour)
+
+	assert.Equal(test.T(), []int{1, 2}, calls)
+}
+
+func (test *PolicyTestSuite) TestWaitForTimersBlocksUntilTimerIsCreated() {
+	clock := policytest.NewFakeClock(start)
+
+	go clock.NewTimer(time.Minute)
+	clock.WaitForTimers(1)
+
+	assert.Equal(test.T(), 1, clock.PendingTimers())
+}
+
+func (test *PolicyTestSuite) TestTimerWithoutDurationFiresRightAway() {
+	clock := policytest.NewFakeClock(start)
+	timer := clock.NewTimer(0)
+
+	assert.Equal(test.T(), start, <-timer.C())
+	assert.Equal(test.T(), 0, clock.PendingTimers())
+}
@@ -0,0 +1,15 @@
+package policytest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PolicyTestSuite struct {
+	suite.Suite
+}
+
+func TestPolicyTest(t *testing.T) {
+	suite.Run(t, new(PolicyTestSuite))
+}
@@ -0,0 +1,216 @@
+// Package tracing traces executions of policies and their attempts with OpenTelemetry
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/typusomega/poligo/pkg/policy"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer spans are created with
+const TracerName = "github.com/typusomega/poligo/pkg/tracing"
+
+// Attribute keys set on the spans
+const (
+	PolicyKey        = attribute.Key("poligo.policy")
+	AttemptKey       = attribute.Key("poligo.attempt.number")
+	SleepDurationKey = attribute.Key("poligo.attempt.sleep_duration")
+	OutcomeKey       = attribute.Key("poligo.outcome")
+	CircuitStateKey  = attribute.Key("poligo.circuit.state")
+)
+
+// Outcomes of executions and attempts
+const (
+	OutcomeSuccess   = "success"
+	OutcomeHandled   = "handled"
+	OutcomeUnhandled = "unhandled"
+)
+
+// RejectedEvent is the name of the span event added for executions rejected by a broken circuit
+const RejectedEvent = "poligo.circuit.rejected"
+
+// Instrument returns a policy applying the given one and tracing its executions
+// Every execution gets a span named after the policy, every attempt a child span of it.
+// Attempts of a RetryPolicy are traced even if they are rejected before reaching the action, e.g. by a broken circuit.
+func Instrument(name string, plcy policy.Policy, opts ...Option) policy.Policy {
+	instrumented := &tracedPolicy{
+		name:           name,
+		policy:         plcy,
+		tracerProvider: otel.GetTracerProvider(),
+	}
+
+	for _, opt := range opts {
+		opt(instrumented)
+	}
+
+	instrumented.tracer = instrumented.tracerProvider.Tracer(TracerName)
+	if breakers := policy.CircuitBreakers(plcy); len(breakers) > 0 {
+		instrumented.breaker = breakers[0]
+	}
+
+	return instrumented
+}
+
+// Option modifies the traced policy
+type Option func(*tracedPolicy)
+
+// WithTracerProvider sets the TracerProvider spans are created with instead of the global one
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *tracedPolicy) {
+		o.tracerProvider = provider
+	}
+}
+
+// tracedPolicy applies a policy and traces its executions
+type tracedPolicy struct {
+	name           string
+	policy         policy.Policy
+	tracerProvider trace.TracerProvider
+	tracer         trace.Tracer
+	breaker        *policy.CircuitBreakerPolicy
+}
+
+// Unwrap returns the traced policy
+func (it *tracedPolicy) Unwrap() policy.Policy {
+	return it.policy
+}
+
+// ExecuteVoid calls the given action and applies the policy
+func (it *tracedPolicy) ExecuteVoid(ctx context.Context, action func() error) error {
+	return it.ExecuteVoidContext(ctx, func(context.Context) error { return action() })
+}
+
+// Execute calls the given action and applies the policy
+func (it *tracedPolicy) Execute(ctx context.Context, action func() (interface{}, error)) (interface{}, error) {
+	return it.ExecuteContext(ctx, func(context.Context) (interface{}, error) { return action() })
+}
+
+// ExecuteVoidContext calls the given action and applies the policy
+func (it *tracedPolicy) ExecuteVoidContext(ctx context.Context, action func(ctx context.Context) error) error {
+	ctx, span := it.tracer.Start(ctx, it.name, trace.WithAttributes(PolicyKey.String(it.name)))
+	defer span.End()
+
+	ctx = policy.WithAttemptObserver(ctx, it.observeAttempt)
+	err := it.policy.ExecuteVoidContext(ctx, func(ctx context.Context) error {
+		if it.isTracedAttempt(ctx) {
+			return action(ctx)
+		}
+
+		ctx, attemptSpan := it.startAttempt(ctx, attemptOf(ctx))
+		defer attemptSpan.End()
+
+		err := action(ctx)
+		it.endSpan(attemptSpan, it.outcome(nil, err), err)
+		return err
+	})
+	it.endSpan(span, it.executionOutcome(nil, err), err)
+
+	return err
+}
+
+// ExecuteContext calls the given action and applies the policy
+func (it *tracedPolicy) ExecuteContext(ctx context.Context, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ctx, span := it.tracer.Start(ctx, it.name, trace.WithAttributes(PolicyKey.String(it.name)))
+	defer span.End()
+
+	ctx = policy.WithAttemptObserver(ctx, it.observeAttempt)
+	val, err := it.policy.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		if it.isTracedAttempt(ctx) {
+			return action(ctx)
+		}
+
+		ctx, attemptSpan := it.startAttempt(ctx, attemptOf(ctx))
+		defer attemptSpan.End()
+
+		val, err := action(ctx)
+		it.endSpan(attemptSpan, it.outcome(val, err), err)
+		return val, err
+	})
+	it.endSpan(span, it.executionOutcome(val, err), err)
+
+	return val, err
+}
+
+// observeAttempt traces an attempt made by a RetryPolicy from its start on, before any policy nested within the RetryPolicy is applied
+func (it *tracedPolicy) observeAttempt(ctx context.Context, attempt policy.Attempt) (context.Context, func(interface{}, error)) {
+	ctx, attemptSpan := it.startAttempt(ctx, attempt)
+	ctx = context.WithValue(ctx, tracedAttemptKey{}, it)
+
+	return ctx, func(val interface{}, err error) {
+		it.endSpan(attemptSpan, it.outcome(val, err), err)
+		attemptSpan.End()
+	}
+}
+
+// tracedAttemptKey marks the context of an attempt traced by the tracedPolicy it carries
+type tracedAttemptKey struct{}
+
+// isTracedAttempt reports whether the attempt handing over the context is traced by the policy already
+func (it *tracedPolicy) isTracedAttempt(ctx context.Context) bool {
+	traced, _ := ctx.Value(tracedAttemptKey{}).(*tracedPolicy)
+	return traced == it
+}
+
+// attemptOf returns the Attempt carried by the context, the first one if there is none
+func attemptOf(ctx context.Context) policy.Attempt {
+	attempt, ok := policy.AttemptFromContext(ctx)
+	if !ok {
+		attempt = policy.Attempt{Number: 1}
+	}
+	return attempt
+}
+
+func (it *tracedPolicy) startAttempt(ctx context.Context, attempt policy.Attempt) (context.Context, trace.Span) {
+	return it.tracer.Start(ctx, it.name+" attempt", trace.WithAttributes(
+		PolicyKey.String(it.name),
+		AttemptKey.Int(attempt.Number),
+		SleepDurationKey.Float64(attempt.Delay.Seconds()),
+	))
+}
+
+// endSpan records the outcome and the circuit's state on the given span
+func (it *tracedPolicy) endSpan(span trace.Span, outcome string, err error) {
+	span.SetAttributes(OutcomeKey.String(outcome))
+	if it.breaker != nil {
+		span.SetAttributes(CircuitStateKey.String(it.breaker.State().String()))
+	}
+
+	if errors.Is(err, policy.CircuitBrokenError{}) {
+		span.AddEvent(RejectedEvent)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// executionOutcome classifies the outcome of a whole execution
+// Giving up retrying and rejecting calls are the policy handling failures, no matter the errors it handles
+func (it *tracedPolicy) executionOutcome(val interface{}, err error) string {
+	if errors.As(err, new(policy.RetryExhaustedError)) || errors.Is(err, policy.CircuitBrokenError{}) {
+		return OutcomeHandled
+	}
+	return it.outcome(val, err)
+}
+
+// outcome classifies the outcome of an attempt, policies not telling their handled outcomes are considered to handle all errors
+func (it *tracedPolicy) outcome(val interface{}, err error) string {
+	handled := err != nil
+	if handler, ok := it.policy.(policy.OutcomeHandler); ok {
+		handled = handler.Handles(val, err)
+	}
+
+	switch {
+	case handled:
+		return OutcomeHandled
+	case err != nil:
+		return OutcomeUnhandled
+	default:
+		return OutcomeSuccess
+	}
+}
@@ -0,0 +1,25 @@
+package tracing_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type TracingSuite struct {
+	suite.Suite
+
+	exporter *tracetest.InMemoryExporter
+	provider *sdktrace.TracerProvider
+}
+
+func TestTracing(t *testing.T) {
+	suite.Run(t, new(TracingSuite))
+}
+
+func (it *TracingSuite) SetupTest() {
+	it.exporter = tracetest.NewInMemoryExporter()
+	it.provider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(it.exporter))
+}
@@ -0,0 +1,150 @@
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/typusomega/poligo/pkg/policy"
+	"github.com/typusomega/poligo/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type customError struct{}
+
+func (customError) Error() string { return "custom" }
+
+func (test *TracingSuite) TestExecutionSpanHasChildSpanPerAttempt() {
+	retry := policy.HandleAll().Retry(policy.WithRetries(2), policy.WithDurations(time.Millisecond, time.Millisecond*2))
+	plcy := tracing.Instrument("retry", retry, tracing.WithTracerProvider(test.provider))
+
+	_ = plcy.ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	spans := test.exporter.GetSpans()
+	assert.Len(test.T(), spans, 4)
+	execution := spans[len(spans)-1]
+	assert.Equal(test.T(), "retry", execution.Name)
+	assert.Equal(test.T(), codes.Error, execution.Status.Code)
+	assert.Equal(test.T(), attribute.StringValue(tracing.OutcomeHandled), attributeOf(execution, tracing.OutcomeKey))
+
+	for i, attempt := range spans[:3] {
+		assert.Equal(test.T(), "retry attempt", attempt.Name)
+		assert.Equal(test.T(), execution.SpanContext.SpanID(), attempt.Parent.SpanID(), "attempt span no child of execution span")
+		assert.Equal(test.T(), attribute.IntValue(i+1), attributeOf(attempt, tracing.AttemptKey))
+		assert.Equal(test.T(), attribute.StringValue(tracing.OutcomeHandled), attributeOf(attempt, tracing.OutcomeKey))
+	}
+	assert.Equal(test.T(), attribute.Float64Value(0), attributeOf(spans[0], tracing.SleepDurationKey))
+	assert.Equal(test.T(), attribute.Float64Value(0.001), attributeOf(spans[1], tracing.SleepDurationKey))
+	assert.Equal(test.T(), attribute.Float64Value(0.002), attributeOf(spans[2], tracing.SleepDurationKey))
+}
+
+func (test *TracingSuite) TestSpansRecordOutcomes() {
+	retry := policy.HandleType(customError{}).Retry()
+	plcy := tracing.Instrument("retry", retry, tracing.WithTracerProvider(test.provider))
+
+	_, _ = plcy.Execute(context.Background(), func() (interface{}, error) { return "test", nil })
+	spans := test.exporter.GetSpans()
+	assert.Len(test.T(), spans, 2)
+	assert.Equal(test.T(), attribute.StringValue(tracing.OutcomeSuccess), attributeOf(spans[0], tracing.OutcomeKey))
+	assert.Equal(test.T(), attribute.StringValue(tracing.OutcomeSuccess), attributeOf(spans[1], tracing.OutcomeKey))
+	assert.Equal(test.T(), codes.Unset, spans[1].Status.Code)
+
+	test.exporter.Reset()
+	_, _ = plcy.Execute(context.Background(), func() (interface{}, error) { return nil, fmt.Errorf("fail") })
+	spans = test.exporter.GetSpans()
+	assert.Len(test.T(), spans, 2, "unhandled error retried")
+	assert.Equal(test.T(), attribute.StringValue(tracing.OutcomeUnhandled), attributeOf(spans[0], tracing.OutcomeKey))
+	assert.Equal(test.T(), attribute.StringValue(tracing.OutcomeUnhandled), attributeOf(spans[1], tracing.OutcomeKey))
+}
+
+func (test *TracingSuite) TestSpansRecordCircuitState() {
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker()
+	circuitBreaker.BrokenForProvider = func(int) (time.Duration, bool) { return time.Hour, true }
+	plcy := tracing.Instrument("circuit", policy.Wrap(policy.DefaultTimeoutPolicy(), circuitBreaker), tracing.WithTracerProvider(test.provider))
+
+	_ = plcy.ExecuteVoid(context.Background(), func() error { return fmt.Errorf("fail") })
+
+	spans := test.exporter.GetSpans()
+	assert.Len(test.T(), spans, 2)
+	assert.Equal(test.T(), attribute.StringValue("closed"), attributeOf(spans[0], tracing.CircuitStateKey), "state at the attempt's end not recorded")
+	assert.Equal(test.T(), attribute.StringValue("open"), attributeOf(spans[1], tracing.CircuitStateKey), "state at the execution's end not recorded")
+}
+
+func (test *TracingSuite) TestRejectionsAreSpanEvents() {
+	circuitBreaker := policy.DefaultCircuitBreakerPolicy()
+	circuitBreaker.Isolate()
+	plcy := tracing.Instrument("circuit", circuitBreaker, tracing.WithTracerProvider(test.provider))
+
+	_ = plcy.ExecuteVoid(context.Background(), func() error { return nil })
+
+	spans := test.exporter.GetSpans()
+	assert.Len(test.T(), spans, 1, "attempt span created for rejected execution")
+	assert.Len(test.T(), spans[0].Events, 2)
+	assert.Equal(test.T(), tracing.RejectedEvent, spans[0].Events[0].Name)
+	assert.Equal(test.T(), attribute.StringValue(tracing.OutcomeHandled), attributeOf(spans[0], tracing.OutcomeKey))
+	assert.Equal(test.T(), attribute.StringValue("isolated"), attributeOf(spans[0], tracing.CircuitStateKey))
+}
+
+func (test *TracingSuite) TestAttemptsRejectedWithinRetriesAreTraced() {
+	retry := policy.HandleAll().Retry(policy.WithRetries(2), policy.WithDurations(time.Millisecond))
+	circuitBreaker := policy.HandleAll().WithCircuitBreaker()
+	circuitBreaker.MaxErrors = 0
+	circuitBreaker.BrokenForProvider = func(int) (time.Duration, bool) { return time.Hour, true }
+	plcy := tracing.Instrument("wrap", policy.Wrap(retry, circuitBreaker), tracing.WithTracerProvider(test.provider))
+
+	calls := 0
+	_ = plcy.ExecuteVoid(context.Background(), func() error {
+		calls++
+		return fmt.Errorf("fail")
+	})
+
+	assert.Equal(test.T(), 1, calls)
+	spans := test.exporter.GetSpans()
+	assert.Len(test.T(), spans, 4, "rejected attempts not traced")
+	assert.Len(test.T(), spans[0].Events, 1, "attempt reaching the action recorded as rejected")
+	for i, attempt := range spans[:3] {
+		assert.Equal(test.T(), "wrap attempt", attempt.Name)
+		assert.Equal(test.T(), attribute.IntValue(i+1), attributeOf(attempt, tracing.AttemptKey))
+		if i > 0 {
+			assert.Len(test.T(), attempt.Events, 2, "rejection of attempt %d not recorded", i+1)
+			assert.Equal(test.T(), tracing.RejectedEvent, attempt.Events[0].Name)
+		}
+	}
+}
+
+func (test *TracingSuite) TestSpansAreChildrenOfCallersSpan() {
+	ctx, parent := test.provider.Tracer("test").Start(context.Background(), "parent")
+	plcy := tracing.Instrument("retry", policy.DefaultRetryPolicy(), tracing.WithTracerProvider(test.provider))
+
+	_ = plcy.ExecuteVoidContext(ctx, func(context.Context) error { return nil })
+	parent.End()
+
+	spans := test.exporter.GetSpans()
+	assert.Equal(test.T(), parent.SpanContext().SpanID(), spans[1].Parent.SpanID(), "execution span no child of caller's span")
+}
+
+func (test *TracingSuite) TestActionReceivesAttemptSpan() {
+	plcy := tracing.Instrument("retry", policy.DefaultRetryPolicy(), tracing.WithTracerProvider(test.provider))
+
+	_ = plcy.ExecuteVoidContext(context.Background(), func(ctx context.Context) error {
+		_, span := test.provider.Tracer("test").Start(ctx, "action")
+		span.End()
+		return nil
+	})
+
+	spans := test.exporter.GetSpans()
+	assert.Len(test.T(), spans, 3)
+	assert.Equal(test.T(), spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID(), "action's span no child of attempt span")
+}
+
+func attributeOf(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}